@@ -0,0 +1,221 @@
+// Package appcast reads and writes WinSparkle appcast feeds.
+//
+// An appcast is an RSS 2.0 feed using the Sparkle XML namespace to describe
+// the available versions of an application. See
+// https://github.com/vslavik/winsparkle/wiki/Appcast-Feeds for more
+// information about appcast feeds.
+//
+// This package doesn't depend on WinSparkle.dll and can be used on any
+// platform, e.g. to generate appcasts as part of a release pipeline.
+package appcast
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+)
+
+// Namespace is the Sparkle XML namespace used by appcast feeds.
+const Namespace = "http://www.andymatuschak.org/xml-namespaces/sparkle"
+
+// Appcast is the root element of an appcast feed.
+type Appcast struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel Channel  `xml:"channel"`
+
+	// Attrs holds the other attributes of the root element, including the
+	// declarations of namespaces used by extra elements.
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+// Channel describes the application and contains its updates.
+type Channel struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link,omitempty"`
+	Description string `xml:"description,omitempty"`
+	Language    string `xml:"language,omitempty"`
+	Items       []Item `xml:"item"`
+
+	// Extra holds the elements not described above, e.g. ones from other
+	// namespaces, so they aren't lost when re-encoding an appcast.
+	Extra []Element `xml:",any"`
+}
+
+// Item describes a single version of the application.
+type Item struct {
+	Title       string `xml:"title,omitempty"`
+	Link        string `xml:"link,omitempty"`
+	Description string `xml:"description,omitempty"`
+	PubDate     *Date  `xml:"pubDate,omitempty"`
+
+	// Version and ShortVersionString may be set on the item instead of on
+	// its enclosures. Use [Item.VersionString] to get the effective version.
+	Version            string `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle version,omitempty"`
+	ShortVersionString string `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle shortVersionString,omitempty"`
+
+	ReleaseNotesLink     string          `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle releaseNotesLink,omitempty"`
+	MinimumSystemVersion string          `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle minimumSystemVersion,omitempty"`
+	CriticalUpdate       *CriticalUpdate `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle criticalUpdate,omitempty"`
+
+	// Enclosures holds the update payloads. WinSparkle picks the first one
+	// whose OS matches the running system.
+	Enclosures []Enclosure `xml:"enclosure"`
+
+	// Extra holds the elements not described above, e.g. sparkle:tags, so
+	// they aren't lost when re-encoding an appcast.
+	Extra []Element `xml:",any"`
+}
+
+// Element is an XML element kept verbatim.
+type Element struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   string     `xml:",innerxml"`
+}
+
+// UnmarshalXML implements [xml.Unmarshaler]. Namespace declarations aren't
+// kept as attributes, as the encoder declares the element's namespace.
+func (el *Element) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain Element
+	if err := d.DecodeElement((*plain)(el), &start); err != nil {
+		return err
+	}
+	el.Attrs = filterAttrs(el.Attrs, func(a xml.Attr) bool { return !isNamespaceDecl(a) })
+	return nil
+}
+
+// UnmarshalXML implements [xml.Unmarshaler]. The declaration of the Sparkle
+// namespace isn't kept in Attrs, as it's always written.
+func (a *Appcast) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain Appcast
+	if err := d.DecodeElement((*plain)(a), &start); err != nil {
+		return err
+	}
+	a.Attrs = filterAttrs(a.Attrs, func(attr xml.Attr) bool {
+		return !isNamespaceDecl(attr) || attr.Name.Space == "xmlns" && attr.Value != Namespace
+	})
+	return nil
+}
+
+func isNamespaceDecl(a xml.Attr) bool {
+	return a.Name.Space == "xmlns" || a.Name.Space == "" && a.Name.Local == "xmlns"
+}
+
+func filterAttrs(attrs []xml.Attr, keep func(xml.Attr) bool) []xml.Attr {
+	var res []xml.Attr
+	for _, a := range attrs {
+		if keep(a) {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+// VersionString returns the item's version, falling back to the version of
+// its first enclosure.
+func (i *Item) VersionString() string {
+	if i.Version != "" || len(i.Enclosures) == 0 {
+		return i.Version
+	}
+	return i.Enclosures[0].Version
+}
+
+// CriticalUpdate marks an item as a critical update.
+type CriticalUpdate struct {
+	// Version is the optional version below which the update is critical.
+	Version string `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle version,attr,omitempty"`
+}
+
+// Enclosure describes an update payload.
+type Enclosure struct {
+	URL                string `xml:"url,attr"`
+	Length             int64  `xml:"length,attr"`
+	Type               string `xml:"type,attr"`
+	Version            string `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle version,attr,omitempty"`
+	ShortVersionString string `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle shortVersionString,attr,omitempty"`
+	DSASignature       string `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle dsaSignature,attr,omitempty"`
+	EdSignature        string `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle edSignature,attr,omitempty"`
+
+	// OS is the operating system the payload is for, e.g. "windows",
+	// "windows-x86", "windows-x64" or "windows-arm64".
+	OS string `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle os,attr,omitempty"`
+
+	// InstallerArguments are passed to the installer when it's run.
+	InstallerArguments string `xml:"http://www.andymatuschak.org/xml-namespaces/sparkle installerArguments,attr,omitempty"`
+}
+
+// Date is a RSS date as used by the pubDate element.
+type Date struct {
+	time.Time
+
+	// Raw is the date as found in the appcast if it couldn't be parsed. It's
+	// written back unchanged, as WinSparkle ignores the date.
+	Raw string
+}
+
+// dateLayouts are the accepted date formats. RFC 822 allows omitting the
+// weekday and single-digit days, and some feeds use ISO 8601 dates.
+var dateLayouts = []string{
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 06 15:04 -0700",
+	"Mon, 2 Jan 06 15:04 MST",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 06 15:04 MST",
+	time.RFC3339,
+	"2006-01-02",
+}
+
+// MarshalText implements [encoding.TextMarshaler].
+func (d Date) MarshalText() ([]byte, error) {
+	if d.Time.IsZero() && d.Raw != "" {
+		return []byte(d.Raw), nil
+	}
+	return []byte(d.Format(time.RFC1123Z)), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler]. Dates which can't be
+// parsed are kept in Raw rather than failing, as WinSparkle ignores them.
+func (d *Date) UnmarshalText(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			*d = Date{Time: t}
+			return nil
+		}
+	}
+	*d = Date{Raw: s}
+	return nil
+}
+
+// Parse reads an appcast from r.
+func Parse(r io.Reader) (*Appcast, error) {
+	a := new(Appcast)
+	if err := xml.NewDecoder(r).Decode(a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Encode writes the appcast to w as indented XML including the XML header.
+func (a *Appcast) Encode(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(a); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
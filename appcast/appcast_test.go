@@ -0,0 +1,166 @@
+package appcast_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abemedia/go-winsparkle/appcast"
+)
+
+//nolint:lll
+const feed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:sparkle="http://www.andymatuschak.org/xml-namespaces/sparkle">
+	<channel>
+		<title>WinSparkle Test Appcast</title>
+		<description>Most recent updates to WinSparkle Test</description>
+		<language>en</language>
+		<item>
+			<title>Version 2.0</title>
+			<description>This is an update.</description>
+			<pubDate>Mon, 28 Jan 2013 14:30:00 +0500</pubDate>
+			<sparkle:releaseNotesLink>https://example.com/2.0.html</sparkle:releaseNotesLink>
+			<sparkle:minimumSystemVersion>10.0</sparkle:minimumSystemVersion>
+			<sparkle:criticalUpdate sparkle:version="1.5"></sparkle:criticalUpdate>
+			<enclosure url="https://example.com/install.msi" length="1024" type="application/octet-stream" sparkle:version="2.0" sparkle:shortVersionString="2.0 Beta" sparkle:edSignature="c2ln" sparkle:os="windows-x64"></enclosure>
+		</item>
+	</channel>
+</rss>
+`
+
+func TestParse(t *testing.T) {
+	a, err := appcast.Parse(strings.NewReader(feed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &appcast.Appcast{
+		Version: "2.0",
+		Channel: appcast.Channel{
+			Title:       "WinSparkle Test Appcast",
+			Description: "Most recent updates to WinSparkle Test",
+			Language:    "en",
+			Items: []appcast.Item{{
+				Title:                "Version 2.0",
+				Description:          "This is an update.",
+				PubDate:              &appcast.Date{Time: time.Date(2013, 1, 28, 14, 30, 0, 0, time.FixedZone("", 5*60*60))},
+				ReleaseNotesLink:     "https://example.com/2.0.html",
+				MinimumSystemVersion: "10.0",
+				CriticalUpdate:       &appcast.CriticalUpdate{Version: "1.5"},
+				Enclosures: []appcast.Enclosure{{
+					URL:                "https://example.com/install.msi",
+					Length:             1024,
+					Type:               "application/octet-stream",
+					Version:            "2.0",
+					ShortVersionString: "2.0 Beta",
+					EdSignature:        "c2ln",
+					OS:                 "windows-x64",
+				}},
+			}},
+		},
+	}
+	a.XMLName = want.XMLName
+
+	if !a.Channel.Items[0].PubDate.Equal(want.Channel.Items[0].PubDate.Time) {
+		t.Errorf("unexpected pubDate: %s", a.Channel.Items[0].PubDate)
+	}
+	a.Channel.Items[0].PubDate = want.Channel.Items[0].PubDate
+
+	if !reflect.DeepEqual(a, want) {
+		t.Errorf("got %+v\nwant %+v", a, want)
+	}
+	if v := a.Channel.Items[0].VersionString(); v != "2.0" {
+		t.Errorf("unexpected version: %q", v)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	a, err := appcast.Parse(strings.NewReader(feed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = a.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); got != feed {
+		t.Errorf("got:\n%s\nwant:\n%s", got, feed)
+	}
+}
+
+//nolint:lll
+const extraFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:sparkle="http://www.andymatuschak.org/xml-namespaces/sparkle" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<channel>
+		<title>Test</title>
+		<dc:creator>Example</dc:creator>
+		<item>
+			<title>Version 1.1</title>
+			<pubDate>Mon, 2 Jan 2013 14:30:00 +0000</pubDate>
+			<sparkle:tags><sparkle:criticalUpdate/></sparkle:tags>
+			<sparkle:minimumAutoupdateVersion>1.0</sparkle:minimumAutoupdateVersion>
+			<enclosure url="https://example.com/1.1.msi" length="1024" type="application/octet-stream" sparkle:version="1.1"></enclosure>
+		</item>
+		<item>
+			<title>Version 1.0</title>
+			<pubDate>sometime in 2012</pubDate>
+			<enclosure url="https://example.com/1.0.msi" length="1024" type="application/octet-stream" sparkle:version="1.0"></enclosure>
+		</item>
+	</channel>
+</rss>
+`
+
+func TestParseDates(t *testing.T) {
+	tests := map[string]time.Time{
+		"Mon, 2 Jan 2013 14:30:00 +0000":  time.Date(2013, 1, 2, 14, 30, 0, 0, time.UTC),
+		"Mon, 02 Jan 2013 14:30:00 +0000": time.Date(2013, 1, 2, 14, 30, 0, 0, time.UTC),
+		"2 Jan 13 14:30 +0000":            time.Date(2013, 1, 2, 14, 30, 0, 0, time.UTC),
+		"2013-01-02T14:30:00Z":            time.Date(2013, 1, 2, 14, 30, 0, 0, time.UTC),
+		"2013-01-02":                      time.Date(2013, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+	for s, want := range tests {
+		var d appcast.Date
+		if err := d.UnmarshalText([]byte(s)); err != nil || !d.Equal(want) || d.Raw != "" {
+			t.Errorf("%s: got %s, %q, %v", s, d.Time, d.Raw, err)
+		}
+	}
+}
+
+func TestEncodeExtra(t *testing.T) {
+	a, err := appcast.Parse(strings.NewReader(extraFeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := a.Channel.Items[1].PubDate; d.Raw != "sometime in 2012" {
+		t.Errorf("should keep invalid date: %+v", d)
+	}
+
+	var buf bytes.Buffer
+	if err = a.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`xmlns:dc="http://purl.org/dc/elements/1.1/"`,
+		`<pubDate>Wed, 02 Jan 2013 14:30:00 +0000</pubDate>`,
+		`<pubDate>sometime in 2012</pubDate>`,
+		`<sparkle:tags><sparkle:criticalUpdate/></sparkle:tags>`,
+		`<sparkle:minimumAutoupdateVersion>1.0</sparkle:minimumAutoupdateVersion>`,
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("missing %s in:\n%s", s, buf.String())
+		}
+	}
+
+	b, err := appcast.Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b.Channel.Items[0].Extra, a.Channel.Items[0].Extra) ||
+		!reflect.DeepEqual(b.Channel.Extra, a.Channel.Extra) {
+		t.Errorf("extra elements changed:\n%+v\n%+v", b.Channel, a.Channel)
+	}
+}
@@ -0,0 +1,99 @@
+package appcast
+
+import "encoding/xml"
+
+// The types below mirror the exported types with prefixed names instead of
+// namespaced ones. encoding/xml would otherwise declare the Sparkle namespace
+// on every element using it rather than once on the root element.
+
+type rss struct {
+	XMLName xml.Name   `xml:"-"`
+	Version string     `xml:"-"`
+	Channel Channel    `xml:"channel"`
+	Attrs   []xml.Attr `xml:"-"`
+}
+
+type item struct {
+	Title                string          `xml:"title,omitempty"`
+	Link                 string          `xml:"link,omitempty"`
+	Description          string          `xml:"description,omitempty"`
+	PubDate              *Date           `xml:"pubDate,omitempty"`
+	Version              string          `xml:"sparkle:version,omitempty"`
+	ShortVersionString   string          `xml:"sparkle:shortVersionString,omitempty"`
+	ReleaseNotesLink     string          `xml:"sparkle:releaseNotesLink,omitempty"`
+	MinimumSystemVersion string          `xml:"sparkle:minimumSystemVersion,omitempty"`
+	CriticalUpdate       *CriticalUpdate `xml:"sparkle:criticalUpdate,omitempty"`
+	Enclosures           []Enclosure     `xml:"enclosure"`
+	Extra                []Element       `xml:",any"`
+}
+
+type criticalUpdate struct {
+	Version string `xml:"sparkle:version,attr,omitempty"`
+}
+
+type enclosure struct {
+	URL                string `xml:"url,attr"`
+	Length             int64  `xml:"length,attr"`
+	Type               string `xml:"type,attr"`
+	Version            string `xml:"sparkle:version,attr,omitempty"`
+	ShortVersionString string `xml:"sparkle:shortVersionString,attr,omitempty"`
+	DSASignature       string `xml:"sparkle:dsaSignature,attr,omitempty"`
+	EdSignature        string `xml:"sparkle:edSignature,attr,omitempty"`
+	OS                 string `xml:"sparkle:os,attr,omitempty"`
+	InstallerArguments string `xml:"sparkle:installerArguments,attr,omitempty"`
+}
+
+// MarshalXML implements [xml.Marshaler].
+func (a Appcast) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if a.Version == "" {
+		a.Version = "2.0"
+	}
+	start = xml.StartElement{
+		Name: xml.Name{Local: "rss"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "version"}, Value: a.Version},
+			{Name: xml.Name{Local: "xmlns:sparkle"}, Value: Namespace},
+		},
+	}
+	for _, attr := range a.Attrs {
+		if attr.Name.Space == "xmlns" {
+			attr.Name = xml.Name{Local: "xmlns:" + attr.Name.Local}
+		}
+		start.Attr = append(start.Attr, attr)
+	}
+	return e.EncodeElement(rss(a), start)
+}
+
+// MarshalXML implements [xml.Marshaler].
+func (i Item) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(item(i), start)
+}
+
+// MarshalXML implements [xml.Marshaler].
+func (c CriticalUpdate) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(criticalUpdate(c), start)
+}
+
+// MarshalXML implements [xml.Marshaler].
+func (c Enclosure) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(enclosure(c), start)
+}
+
+// MarshalXML implements [xml.Marshaler]. Elements and attributes from the
+// Sparkle namespace use the prefix declared on the root element.
+func (el Element) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: prefixed(el.XMLName)}
+	for _, a := range el.Attrs {
+		start.Attr = append(start.Attr, xml.Attr{Name: prefixed(a.Name), Value: a.Value})
+	}
+	return e.EncodeElement(struct {
+		Inner string `xml:",innerxml"`
+	}{el.Inner}, start)
+}
+
+func prefixed(n xml.Name) xml.Name {
+	if n.Space == Namespace {
+		return xml.Name{Local: "sparkle:" + n.Local}
+	}
+	return n
+}
@@ -0,0 +1,137 @@
+package appcast
+
+import (
+	"strconv"
+	"strings"
+)
+
+type charType int
+
+const (
+	typeNumber charType = iota
+	typePeriod
+	typeString
+)
+
+func classify(c byte) charType {
+	switch {
+	case c == '.':
+		return typePeriod
+	case c >= '0' && c <= '9':
+		return typeNumber
+	default:
+		return typeString
+	}
+}
+
+// splitVersion splits a version string into its components. A component is a
+// continuous run of characters with the same classification, e.g. "1.20rc3" is
+// split into ["1", ".", "20", "rc", "3"]. Every period is its own component.
+func splitVersion(version string) []string {
+	if version == "" {
+		return nil
+	}
+
+	var parts []string
+	start := 0
+	prev := classify(version[0])
+	for i := 1; i < len(version); i++ {
+		t := classify(version[i])
+		if t != prev || prev == typePeriod {
+			parts = append(parts, version[start:i])
+			start = i
+		}
+		prev = t
+	}
+	return append(parts, version[start:])
+}
+
+// compareNumbers compares two strings of decimal digits numerically. Like
+// atoi in WinSparkle, numbers that don't fit into a 32-bit int saturate at
+// 2147483647, so any two such numbers are equal.
+func compareNumbers(a, b string) int {
+	// ParseInt returns the saturated value alongside the range error.
+	x, _ := strconv.ParseInt(a, 10, 32)
+	y, _ := strconv.ParseInt(b, 10, 32)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// CompareVersions compares two version strings the same way as WinSparkle
+// does when deciding whether an appcast item is newer than the running
+// application.
+//
+// It returns a negative value if a is older than b, zero if they're equal and
+// a positive value if a is newer than b.
+//
+// Versions are split into numeric, alphabetic and period components which
+// are compared in order. Numbers are compared numerically, saturating at
+// 2147483647 like WinSparkle, and strings lexically. A string is considered a pre-release, so "1.0a" < "1.0" and
+// "1.2rc1" < "1.2.0", while additional numeric components make a version
+// newer, so "1.0" < "1.0.1".
+func CompareVersions(a, b string) int {
+	partsA := splitVersion(a)
+	partsB := splitVersion(b)
+
+	// Compare the common length of both versions.
+	n := len(partsA)
+	if len(partsB) < n {
+		n = len(partsB)
+	}
+	for i := 0; i < n; i++ {
+		typeA := classify(partsA[i][0])
+		typeB := classify(partsB[i][0])
+
+		if typeA == typeB {
+			var r int
+			switch typeA {
+			case typeString:
+				r = strings.Compare(partsA[i], partsB[i])
+			case typeNumber:
+				r = compareNumbers(partsA[i], partsB[i])
+			case typePeriod:
+			}
+			if r != 0 {
+				return r
+			}
+			continue
+		}
+
+		switch {
+		case typeB == typeString:
+			return 1 // 1.2.0 > 1.2rc1
+		case typeA == typeString:
+			return -1 // 1.2rc1 < 1.2.0
+		case typeA == typeNumber:
+			return 1 // One is a number and the other an invalid period.
+		default:
+			return -1
+		}
+	}
+
+	if len(partsA) == len(partsB) {
+		return 0
+	}
+
+	// The versions are equal up to the length of the shorter one, so the
+	// next component of the longer one decides.
+	var missing charType
+	shorter, longer := 1, -1
+	if len(partsA) > len(partsB) {
+		missing = classify(partsA[n][0])
+		shorter, longer = -1, 1
+	} else {
+		missing = classify(partsB[n][0])
+	}
+
+	if missing == typeString {
+		return shorter // 1.5 > 1.5b3
+	}
+	return longer // 1.5.1 > 1.5
+}
@@ -0,0 +1,56 @@
+package appcast_test
+
+import (
+	"testing"
+
+	"github.com/abemedia/go-winsparkle/appcast"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.1", -1},
+		{"2.0", "1.0", 1},
+		{"1.9", "1.10", -1},
+		{"1.0", "1.0.1", -1},
+		{"1.0", "1.0.0", -1},
+		{"1.0a", "1.0", -1},
+		{"1.0a", "1.0b", -1},
+		{"1.0b1", "1.0b2", -1},
+		{"1.0b10", "1.0b2", 1},
+		{"1.0b1", "1.0", -1},
+		{"1.5", "1.5b3", 1},
+		{"1.2rc1", "1.2.0", -1},
+		{"1.2.0", "1.2rc1", 1},
+		{"1.0", "1..0", 1},
+		{"001.2", "1.2", 0},
+		{"99999999999999999999", "1", 1},
+		{"2147483647", "2147483646", 1},
+		{"1.2147483648", "1.99999999999", 0},
+		{"1.2147483648", "1.2147483647", 0},
+		{"", "1.0", -1},
+		{"", "", 0},
+	}
+	for _, test := range tests {
+		if got := appcast.CompareVersions(test.a, test.b); sign(got) != test.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+		if got := appcast.CompareVersions(test.b, test.a); sign(got) != -test.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", test.b, test.a, got, -test.want)
+		}
+	}
+}
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,193 @@
+package winsparkle
+
+import "sync"
+
+// hook manages one of WinSparkle's callbacks. It holds the function set by
+// the Set*Callback function and any number of listeners, and passes a single
+// native callback to WinSparkle dispatching to them.
+//
+// The native callback is created once, as Go limits the number of callbacks
+// per process, and only passed to WinSparkle while there are handlers, so
+// WinSparkle's default behaviour applies otherwise.
+type hook[F any] struct {
+	proc   *proc
+	native func(h *hook[F]) uintptr
+	regMu  sync.Mutex
+
+	mu         sync.Mutex
+	trampoline uintptr
+	callback   F
+	isSet      bool
+	keep       int
+	listeners  []listener[F]
+	nextID     int
+}
+
+type listener[F any] struct {
+	id int
+	fn F
+}
+
+// newHook returns a hook for the callback set by p. native is called to
+// create the native callback.
+func newHook[F any](p *proc, native func(h *hook[F]) uintptr) *hook[F] {
+	return &hook[F]{proc: p, native: native}
+}
+
+// set sets the function passed to the Set*Callback function. Pass ok false to
+// unset it.
+func (h *hook[F]) set(fn F, ok bool) error {
+	return h.update(func() { h.callback, h.isSet = fn, ok })
+}
+
+// add adds a listener. If registering the native callback fails the listener
+// isn't added.
+func (h *hook[F]) add(fn F) (remove func(), err error) {
+	var id int
+	if err = h.update(func() { id = h.addLocked(fn) }); err != nil {
+		h.mu.Lock()
+		h.removeLocked(id)
+		h.mu.Unlock()
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			_ = h.update(func() { h.removeLocked(id) }) // Only fails if the DLL failed to load.
+		})
+	}, nil
+}
+
+// addLocked adds a listener without registering the native callback.
+func (h *hook[F]) addLocked(fn F) int {
+	id := h.nextID
+	h.nextID++
+	h.listeners = append(h.listeners, listener[F]{id: id, fn: fn})
+	return id
+}
+
+func (h *hook[F]) removeLocked(id int) {
+	for i, l := range h.listeners {
+		if l.id == id {
+			h.listeners = append(h.listeners[:i:i], h.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+// register passes the native callback to WinSparkle even without handlers,
+// until unregister was called as often as register.
+func (h *hook[F]) register() error {
+	return h.update(func() { h.keep++ })
+}
+
+// unregister undoes a call to register.
+func (h *hook[F]) unregister() error {
+	return h.update(func() {
+		if h.keep > 0 {
+			h.keep--
+		}
+	})
+}
+
+// update calls change with h.mu held, then passes the native callback to
+// WinSparkle if there are handlers and resets it otherwise.
+//
+// WinSparkle is called without holding h.mu, as it may call back into the
+// handlers from its own thread while setting the callback. Updates are
+// serialised by regMu instead, so WinSparkle is left with the latest state.
+func (h *hook[F]) update(change func()) error {
+	h.regMu.Lock()
+	defer h.regMu.Unlock()
+
+	h.mu.Lock()
+	change()
+	var fn uintptr
+	if h.keep > 0 || h.isSet || len(h.listeners) > 0 {
+		if h.trampoline == 0 {
+			h.trampoline = h.native(h)
+		}
+		fn = h.trampoline
+	}
+	h.mu.Unlock()
+
+	_, _, err := h.proc.call(fn)
+	return err
+}
+
+// handlers returns the function set by the Set*Callback function followed by
+// the listeners in the order they were added.
+func (h *hook[F]) handlers() []F {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fns := make([]F, 0, len(h.listeners)+1)
+	if h.isSet {
+		fns = append(fns, h.callback)
+	}
+	for _, l := range h.listeners {
+		fns = append(fns, l.fn)
+	}
+	return fns
+}
+
+var canShutdownHook = newHook(procSetCanShutdownCallback, func(h *hook[func() bool]) uintptr {
+	return newCallback(func() uintptr { return boolean(canShutdown(h)) })
+})
+
+// AddCanShutdownListener adds a function asking the application if it can be
+// closed, like the one set by [SetCanShutdownCallback]. WinSparkle only shuts
+// down the application if all of them return true.
+//
+// Call remove to remove the listener.
+func AddCanShutdownListener(fn func() bool) (remove func(), err error) {
+	return canShutdownHook.add(fn)
+}
+
+// canShutdown returns whether all handlers agree to shut down. A panicking
+// handler counts as disagreeing.
+func canShutdown(h *hook[func() bool]) bool {
+	for _, fn := range h.handlers() {
+		var ok bool
+		if !protect(func() { ok = fn() }) || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+var userRunInstallerHook = newHook(procSetUserRunInstallerCallback, func(h *hook[func(string) (bool, error)]) uintptr {
+	return newCallback(func(p *uint16) int { return userRunInstaller(h, utf16PtrToString(p)) })
+})
+
+// AddUserRunInstallerListener adds a function called with the downloaded
+// update, like the one set by [SetUserRunInstallerCallback]. The functions
+// are called in order until one of them handles the update or returns an
+// error.
+//
+// Call remove to remove the listener.
+func AddUserRunInstallerListener(fn func(file string) (handled bool, err error)) (remove func(), err error) {
+	return userRunInstallerHook.add(fn)
+}
+
+// userRunInstaller calls the handlers until one of them handles the update or
+// returns an error. A panicking handler counts as not handling the update.
+func userRunInstaller(h *hook[func(string) (bool, error)], file string) int {
+	for _, fn := range h.handlers() {
+		var (
+			handled bool
+			err     error
+		)
+		if !protect(func() { handled, err = fn(file) }) {
+			continue
+		}
+		if err != nil {
+			diag.installerFailed(err)
+			return -1
+		}
+		if handled {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,127 @@
+package winsparkle
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHookHandlers(t *testing.T) {
+	h := testHook[func() string](procSetDidFindUpdateCallback)
+	h.set(func() string { return "callback" }, true)
+	h.mu.Lock()
+	h.addLocked(func() string { return "a" })
+	id := h.addLocked(func() string { return "b" })
+	h.addLocked(func() string { return "c" })
+	h.removeLocked(id)
+	h.mu.Unlock()
+
+	var got []string
+	for _, fn := range h.handlers() {
+		got = append(got, fn())
+	}
+	if want := []string{"callback", "a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	h.set(nil, false)
+	if n := len(h.handlers()); n != 2 {
+		t.Errorf("unexpected number of handlers: %d", n)
+	}
+}
+
+func TestHookAddUnsupported(t *testing.T) {
+	h := testHook[func()](procSetDidFindUpdateCallback)
+	if _, err := h.add(func() {}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if n := len(h.handlers()); n != 0 {
+		t.Error("should not add listener on error")
+	}
+}
+
+func TestHookRegister(t *testing.T) {
+	h := testHook[func()](procSetDidFindUpdateCallback)
+	h.register()
+	h.register()
+	h.unregister()
+	if h.keep != 1 {
+		t.Errorf("should stay registered: %d", h.keep)
+	}
+	h.unregister()
+	h.unregister() // Safe to call too often.
+	if h.keep != 0 {
+		t.Errorf("should be unregistered: %d", h.keep)
+	}
+}
+
+func TestCanShutdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []bool
+		want    bool
+	}{
+		{"none", nil, true},
+		{"all", []bool{true, true}, true},
+		{"one refuses", []bool{true, false, true}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := testHook[func() bool](procSetCanShutdownCallback)
+			for _, answer := range test.answers {
+				answer := answer
+				h.addLocked(func() bool { return answer })
+			}
+			if got := canShutdown(h); got != test.want {
+				t.Errorf("got %t want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUserRunInstaller(t *testing.T) {
+	type result struct {
+		handled bool
+		err     error
+	}
+	tests := []struct {
+		name    string
+		results []result
+		want    int
+		calls   int
+	}{
+		{"none", nil, 0, 0},
+		{"not handled", []result{{}, {}}, 0, 2},
+		{"handled", []result{{}, {handled: true}, {}}, 1, 2},
+		{"error", []result{{err: errors.New("failed")}, {handled: true}}, -1, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := testHook[func(string) (bool, error)](procSetUserRunInstallerCallback)
+			calls := 0
+			for _, r := range test.results {
+				r := r
+				h.addLocked(func(file string) (bool, error) {
+					calls++
+					if file != "update.exe" {
+						t.Errorf("unexpected file: %q", file)
+					}
+					return r.handled, r.err
+				})
+			}
+			if got := userRunInstaller(h, "update.exe"); got != test.want {
+				t.Errorf("got %d want %d", got, test.want)
+			}
+			if calls != test.calls {
+				t.Errorf("got %d calls want %d", calls, test.calls)
+			}
+		})
+	}
+}
+
+// testHook returns a hook without a native callback.
+func testHook[F any](p *proc) *hook[F] {
+	return newHook(p, func(*hook[F]) uintptr { return 0 })
+}
@@ -0,0 +1,121 @@
+package winsparkle
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// ErrCheckFailed is returned by [CheckForUpdate] if WinSparkle reports an
+// error.
+var ErrCheckFailed = errors.New("winsparkle: update check failed")
+
+// Result is the outcome of an update check.
+type Result int
+
+// The results of an update check.
+const (
+	// UpdateAvailable means an update was found. WinSparkle goes on to show
+	// the update dialog.
+	UpdateAvailable Result = iota + 1
+
+	// NoUpdateAvailable means the application is up to date.
+	NoUpdateAvailable
+
+	// UpdateDismissed means an update was found and the user closed the update
+	// dialog without choosing what to do with it.
+	UpdateDismissed
+)
+
+var resultNames = map[Result]string{
+	UpdateAvailable:   "update available",
+	NoUpdateAvailable: "no update available",
+	UpdateDismissed:   "update dismissed",
+}
+
+func (r Result) String() string {
+	if s, ok := resultNames[r]; ok {
+		return s
+	}
+	return "Result(" + strconv.Itoa(int(r)) + ")"
+}
+
+// CheckForUpdate checks for updates like [CheckUpdateWithoutUI] and waits
+// until WinSparkle reports the result or ctx is done. If WinSparkle reports
+// an error it returns an [*UpdateError], which matches [ErrCheckFailed].
+//
+// Events of concurrent update checks can't be told apart, so only one check
+// should run at a time.
+func CheckForUpdate(ctx context.Context) (Result, error) {
+	return WaitForResult(ctx, Default, Default.CheckUpdateWithoutUI)
+}
+
+// CheckForUpdateWithUI checks for updates like [CheckUpdateWithUI] and waits
+// for the result like [WaitForResultWithUI].
+func CheckForUpdateWithUI(ctx context.Context) (Result, error) {
+	return WaitForResultWithUI(ctx, Default, Default.CheckUpdateWithUI)
+}
+
+// WaitForResult subscribes to the events of u, calls check and waits until
+// the update check reports whether an update was found, or ctx is done. It's
+// used to implement [Updater.CheckForUpdate] for other implementations of
+// [Updater].
+func WaitForResult(ctx context.Context, u Updater, check func() error) (Result, error) {
+	return waitForResult(ctx, u, check, false)
+}
+
+// WaitForResultWithUI is like [WaitForResult] but, once an update was found,
+// keeps waiting until the user answered the update dialog. It returns
+// [UpdateDismissed] if the dialog was closed and [UpdateAvailable] if the
+// update was skipped, postponed or cancelled, or once WinSparkle requests
+// shutdown to install it. The latter is only reported with a shutdown request
+// callback or listener, see [Subscribe]; if WinSparkle doesn't request
+// shutdown, e.g. because a user run installer callback handled the update, it
+// waits until ctx is done.
+//
+// It's used to implement [Updater.CheckForUpdateWithUI] for other
+// implementations of [Updater].
+func WaitForResultWithUI(ctx context.Context, u Updater, check func() error) (Result, error) {
+	return waitForResult(ctx, u, check, true)
+}
+
+// waitForResult implements [WaitForResult] and, if ui is true,
+// [WaitForResultWithUI].
+func waitForResult(ctx context.Context, u Updater, check func() error, ui bool) (Result, error) {
+	events, unsubscribe := u.Subscribe(len(eventProcs))
+	defer unsubscribe()
+
+	if err := check(); err != nil {
+		return 0, err
+	}
+
+	var found bool
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case e := <-events:
+			switch e.Kind {
+			case EventDidFindUpdate:
+				if !ui {
+					return UpdateAvailable, nil
+				}
+				found = true
+			case EventDidNotFindUpdate:
+				return NoUpdateAvailable, nil
+			case EventUpdateDismissed:
+				return UpdateDismissed, nil
+			case EventUpdateSkipped, EventUpdatePostponed, EventUpdateCancelled, EventShutdownRequested:
+				if found {
+					return UpdateAvailable, nil
+				}
+			case EventError:
+				if e.Err != nil {
+					return 0, e.Err
+				}
+				return 0, ErrCheckFailed
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abemedia/go-winsparkle/appcast"
+	"github.com/abemedia/go-winsparkle/sign"
+)
+
+type config struct {
+	Dir        string
+	Output     string
+	KeyFile    string
+	Key        string
+	DSAKeyFile string
+	DSAKey     string
+	URLPrefix  string
+	Title      string
+	Link       string
+}
+
+var installerExts = map[string]bool{".exe": true, ".msi": true, ".msp": true}
+
+var archs = map[string]string{
+	"x86":   "windows-x86",
+	"386":   "windows-x86",
+	"win32": "windows-x86",
+	"x64":   "windows-x64",
+	"amd64": "windows-x64",
+	"arm64": "windows-arm64",
+}
+
+var (
+	versionRE    = regexp.MustCompile(`^[vV]?(\d+(?:\.\d+)+[0-9A-Za-z]*)$`)
+	preReleaseRE = regexp.MustCompile(`(?i)^(?:alpha|beta|pre|preview|rc|dev)\.?\d*$`)
+)
+
+// parseName returns the version and sparkle:os value of an installer from its
+// file name. The version must contain a dot and may be followed by a
+// pre-release token, e.g. MyApp-1.0.0-beta.1.exe gives 1.0.0-beta.1. Names with
+// more than one version are ambiguous and rejected.
+func parseName(name string) (version, osName string, ok bool) {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	toks := strings.FieldsFunc(base, func(r rune) bool { return r == '-' || r == '_' || r == ' ' })
+	for i := 0; i < len(toks); i++ {
+		if arch, isArch := archs[strings.ToLower(toks[i])]; isArch {
+			osName = arch
+			continue
+		}
+		m := versionRE.FindStringSubmatch(toks[i])
+		if m == nil {
+			continue
+		}
+		if version != "" {
+			return "", "", false
+		}
+		version = m[1]
+		if i+1 < len(toks) && preReleaseRE.MatchString(toks[i+1]) {
+			version += "-" + toks[i+1]
+			i++
+		}
+	}
+	return version, osName, version != ""
+}
+
+// scan signs the installers in the directory and returns an item per version.
+func scan(c *config) ([]appcast.Item, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []appcast.Item
+	index := map[string]int{}
+
+	for _, e := range entries {
+		if e.IsDir() || !installerExts[strings.ToLower(filepath.Ext(e.Name()))] {
+			continue
+		}
+		version, osName, ok := parseName(e.Name())
+		if !ok {
+			continue
+		}
+
+		enc, modTime, err := enclosure(c, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		enc.Version = version
+		enc.OS = osName
+
+		i, ok := index[version]
+		if !ok {
+			i = len(items)
+			index[version] = i
+			items = append(items, appcast.Item{Title: "Version " + version})
+			if _, err = os.Stat(filepath.Join(c.Dir, version+".html")); err == nil {
+				items[i].ReleaseNotesLink = downloadURL(c.URLPrefix, version+".html")
+			}
+		}
+
+		item := &items[i]
+		item.Enclosures = append(item.Enclosures, enc)
+		if item.PubDate == nil || modTime.After(item.PubDate.Time) {
+			item.PubDate = &appcast.Date{Time: modTime.UTC().Truncate(time.Second)}
+		}
+	}
+
+	return items, nil
+}
+
+func enclosure(c *config, name string) (appcast.Enclosure, time.Time, error) {
+	f, err := os.Open(filepath.Join(c.Dir, name))
+	if err != nil {
+		return appcast.Enclosure{}, time.Time{}, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return appcast.Enclosure{}, time.Time{}, err
+	}
+
+	enc := appcast.Enclosure{
+		URL:  downloadURL(c.URLPrefix, name),
+		Type: "application/octet-stream",
+	}
+
+	if c.DSAKey != "" {
+		if enc.DSASignature, err = sign.SignDSA(c.DSAKey, f); err != nil {
+			return appcast.Enclosure{}, time.Time{}, err
+		}
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return appcast.Enclosure{}, time.Time{}, err
+		}
+	}
+
+	if enc.EdSignature, enc.Length, err = sign.SignEdDSA(c.Key, f); err != nil {
+		return appcast.Enclosure{}, time.Time{}, err
+	}
+
+	return enc, fi.ModTime(), nil
+}
+
+func downloadURL(prefix, name string) string {
+	return strings.TrimSuffix(prefix, "/") + "/" + url.PathEscape(name)
+}
+
+// merge adds the items to the appcast, updating existing items of the same
+// version, and sorts the items from newest to oldest version.
+func merge(a *appcast.Appcast, items []appcast.Item) {
+	for _, item := range items {
+		i := findItem(a.Channel.Items, item.VersionString())
+		if i < 0 {
+			a.Channel.Items = append(a.Channel.Items, item)
+			continue
+		}
+
+		old := &a.Channel.Items[i]
+		if old.Title == "" {
+			old.Title = item.Title
+		}
+		if old.PubDate == nil {
+			old.PubDate = item.PubDate
+		}
+		if item.ReleaseNotesLink != "" {
+			old.ReleaseNotesLink = item.ReleaseNotesLink
+		}
+		for _, enc := range item.Enclosures {
+			old.Enclosures = mergeEnclosure(old.Enclosures, enc)
+		}
+	}
+
+	sort.SliceStable(a.Channel.Items, func(i, j int) bool {
+		x, y := a.Channel.Items[i].VersionString(), a.Channel.Items[j].VersionString()
+		return appcast.CompareVersions(x, y) > 0
+	})
+}
+
+func findItem(items []appcast.Item, version string) int {
+	for i := range items {
+		if items[i].VersionString() == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeEnclosure updates the enclosure with the same URL, keeping fields that
+// can't be generated such as installer arguments, or adds a new one.
+func mergeEnclosure(encs []appcast.Enclosure, enc appcast.Enclosure) []appcast.Enclosure {
+	for i := range encs {
+		if encs[i].URL == enc.URL {
+			enc.InstallerArguments = encs[i].InstallerArguments
+			if encs[i].ShortVersionString != "" {
+				enc.ShortVersionString = encs[i].ShortVersionString
+			}
+			encs[i] = enc
+			return encs
+		}
+	}
+	return append(encs, enc)
+}
+
+func readAppcast(name string) (*appcast.Appcast, error) {
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &appcast.Appcast{Channel: appcast.Channel{Title: "Updates"}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return appcast.Parse(f)
+}
+
+// writeAppcast atomically replaces the file with the appcast.
+func writeAppcast(name string, a *appcast.Appcast) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err = a.Encode(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/abemedia/go-winsparkle/sign"
+)
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name, version, os string
+	}{
+		{"MyApp-1.2.3.exe", "1.2.3", ""},
+		{"MyApp_v1.2.3_x64.msi", "1.2.3", "windows-x64"},
+		{"MyApp-1.0rc1-arm64.exe", "1.0rc1", "windows-arm64"},
+		{"My App 2.0 x86.exe", "2.0", "windows-x86"},
+		{"MyApp-1.0.0-beta.1.exe", "1.0.0-beta.1", ""},
+		{"MyApp_2.1_RC2_x64.msi", "2.1-RC2", "windows-x64"},
+		{"MyApp-64bit-1.0.exe", "1.0", ""},
+		{"MyApp-1.0-setup.exe", "1.0", ""},
+		{"MyApp-2-x64.exe", "", "windows-x64"},
+		{"MyApp-1.0-2.0.exe", "", ""},
+		{"MyApp.exe", "", ""},
+	}
+	for _, test := range tests {
+		version, osName, ok := parseName(test.name)
+		if version != test.version || osName != test.os || ok != (test.version != "") {
+			t.Errorf("%s: got %q, %q, %t", test.name, version, osName, ok)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	pub, priv, err := sign.GenerateEdDSAKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	c := &config{
+		Dir:       dir,
+		Output:    filepath.Join(dir, "appcast.xml"),
+		Key:       priv,
+		URLPrefix: "https://example.com/downloads/",
+		Title:     "My App",
+	}
+
+	writeFile(t, filepath.Join(dir, "MyApp-1.0.0.exe"), "1.0.0", time.Unix(1000, 0))
+	if err = run(c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add installer arguments by hand, which should be kept.
+	a, err := readAppcast(c.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Channel.Items[0].Enclosures[0].InstallerArguments = "/quiet"
+	if err = writeAppcast(c.Output, a); err != nil {
+		t.Fatal(err)
+	}
+
+	// Release a new version and remove the old one.
+	os.Remove(filepath.Join(dir, "MyApp-1.0.0.exe"))
+	writeFile(t, filepath.Join(dir, "MyApp-1.1.0-x64.exe"), "1.1.0 x64", time.Unix(2000, 0))
+	writeFile(t, filepath.Join(dir, "MyApp-1.1.0-arm64.exe"), "1.1.0 arm64", time.Unix(2000, 0))
+	writeFile(t, filepath.Join(dir, "1.1.0.html"), "<p>Release notes</p>", time.Unix(2000, 0))
+	if err = run(c); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err = readAppcast(c.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Channel.Title != "My App" {
+		t.Errorf("unexpected title: %q", a.Channel.Title)
+	}
+	if len(a.Channel.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(a.Channel.Items))
+	}
+
+	item := a.Channel.Items[0]
+	if item.VersionString() != "1.1.0" || len(item.Enclosures) != 2 {
+		t.Fatalf("unexpected item: %+v", item)
+	}
+	if item.ReleaseNotesLink != "https://example.com/downloads/1.1.0.html" {
+		t.Errorf("unexpected release notes link: %q", item.ReleaseNotesLink)
+	}
+	if !item.PubDate.Equal(time.Unix(2000, 0)) {
+		t.Errorf("unexpected pubDate: %s", item.PubDate)
+	}
+	for _, enc := range item.Enclosures {
+		f, err := os.Open(filepath.Join(dir, filepath.Base(enc.URL)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = sign.VerifyEdDSA(pub, f, enc.EdSignature); err != nil {
+			t.Errorf("%s: %v", enc.URL, err)
+		}
+		f.Close()
+	}
+
+	item = a.Channel.Items[1]
+	if item.VersionString() != "1.0.0" || item.Enclosures[0].InstallerArguments != "/quiet" {
+		t.Errorf("unexpected item: %+v", item)
+	}
+}
+
+func writeFile(t *testing.T, name, data string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,109 @@
+// Command generate-appcast generates a WinSparkle appcast from a directory of
+// update payloads.
+//
+// Every installer in the directory (*.exe, *.msi and *.msp) is signed with the
+// EdDSA private key and added to the appcast. The version is taken from the
+// file name, e.g. MyApp-1.2.3.exe, MyApp_1.2.3_x64.msi or MyApp-2.0-beta.1.exe,
+// and must contain a dot. Files with no version or more than one are skipped.
+// Installers with an architecture suffix (x86, x64 or arm64) set the
+// enclosure's sparkle:os attribute and installers of the same version are
+// combined into one item.
+//
+// Release notes are linked if a file named after the version exists in the
+// directory, e.g. 1.2.3.html.
+//
+// If the output file already exists, its items are kept and only updated with
+// the versions found in the directory.
+//
+// Usage:
+//
+//	generate-appcast [flags] directory
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	var c config
+	flag.StringVar(&c.Output, "o", "", "output file (default directory/appcast.xml)")
+	flag.StringVar(&c.KeyFile, "f", "", "file to read the EdDSA private key from (default $WINSPARKLE_PRIVATE_KEY)")
+	flag.StringVar(&c.DSAKeyFile, "dsa", "", "file to read the legacy DSA private key from")
+	flag.StringVar(&c.URLPrefix, "url-prefix", "", "URL prefix for downloads and release notes")
+	flag.StringVar(&c.Title, "title", "", "appcast title")
+	flag.StringVar(&c.Link, "link", "", "link to the application's website")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] directory\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 || c.URLPrefix == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	c.Dir = flag.Arg(0)
+	if c.Output == "" {
+		c.Output = filepath.Join(c.Dir, "appcast.xml")
+	}
+
+	if err := loadKeys(&c); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := run(&c); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func loadKeys(c *config) error {
+	if c.KeyFile != "" {
+		b, err := os.ReadFile(c.KeyFile)
+		if err != nil {
+			return err
+		}
+		c.Key = string(b)
+	} else if c.Key = os.Getenv("WINSPARKLE_PRIVATE_KEY"); c.Key == "" {
+		return errors.New("no private key: use -f or set WINSPARKLE_PRIVATE_KEY")
+	}
+
+	if c.DSAKeyFile != "" {
+		b, err := os.ReadFile(c.DSAKeyFile)
+		if err != nil {
+			return err
+		}
+		c.DSAKey = string(b)
+	}
+
+	return nil
+}
+
+func run(c *config) error {
+	a, err := readAppcast(c.Output)
+	if err != nil {
+		return err
+	}
+
+	items, err := scan(c)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("no installers found in %s", c.Dir)
+	}
+
+	merge(a, items)
+	if c.Title != "" {
+		a.Channel.Title = c.Title
+	}
+	if c.Link != "" {
+		a.Channel.Link = c.Link
+	}
+
+	return writeAppcast(c.Output, a)
+}
@@ -0,0 +1,71 @@
+// Command winsparkle-keys generates an EdDSA (ed25519) key pair for signing
+// WinSparkle updates.
+//
+// The private key is written to a file which must not exist yet. The public
+// key is printed to stdout in the format accepted by
+// winsparkle.SetEdDSAPublicKey.
+//
+// Usage:
+//
+//	winsparkle-keys [-o private.key]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/abemedia/go-winsparkle/sign"
+)
+
+func main() {
+	out := flag.String("o", "eddsa_priv.key", "file to write the private key to")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-o private.key]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(file string) error {
+	pub, priv, err := sign.GenerateEdDSAKey()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%s already exists, move it aside or be more careful", file)
+		}
+		return err
+	}
+	if _, err = fmt.Fprintln(f, priv); err != nil {
+		f.Close()
+		os.Remove(file)
+		return err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(file)
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Private key written to %s. Keep it secret and don't share it!\n", file)
+	fmt.Fprintln(os.Stderr, "BACK UP YOUR PRIVATE KEY AND KEEP IT SAFE!")
+	fmt.Fprintln(os.Stderr, "If you lose it, your users will be unable to upgrade!")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Public key to pass to winsparkle.SetEdDSAPublicKey:")
+	fmt.Println(pub)
+
+	return nil
+}
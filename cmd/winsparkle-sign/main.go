@@ -0,0 +1,180 @@
+// Command winsparkle-sign signs WinSparkle update payloads with an EdDSA
+// (ed25519) private key and verifies their signatures.
+//
+// When signing it prints the sparkle:edSignature and length attributes to add
+// to the update's enclosure in the appcast. The private key is read from the
+// file passed with -f, or from the WINSPARKLE_PRIVATE_KEY environment variable.
+//
+// While migrating from DSA to EdDSA, pass the legacy DSA private key with -dsa
+// to also print the sparkle:dsaSignature attribute.
+//
+// The sign subcommand is the default. Name it explicitly, or pass -- before
+// the file name, to sign a file named "verify".
+//
+// The verify subcommand checks a signature against the base64 encoded public
+// key passed to winsparkle.SetEdDSAPublicKey, or the DSA public key passed to
+// winsparkle.SetDSAPubPEM, and exits with a non-zero status if it doesn't
+// match.
+//
+// Usage:
+//
+//	winsparkle-sign [sign] [-f private.key] [-dsa dsa_priv.pem] update_file
+//	winsparkle-sign verify -k public_key | -dsa dsa_pub.pem update_file signature
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/abemedia/go-winsparkle/sign"
+)
+
+// errUsage is returned after printing the usage for invalid arguments.
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	err := run(os.Args[1:], os.Stdout)
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, w io.Writer) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "sign":
+			return signCmd(args[1:], w)
+		case "verify":
+			return verifyCmd(args[1:], w)
+		}
+	}
+	return signCmd(args, w)
+}
+
+func signCmd(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("winsparkle-sign", flag.ContinueOnError)
+	keyFile := fs.String("f", "", "file to read the private key from")
+	dsaFile := fs.String("dsa", "", "file to read the legacy DSA private key from")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [sign] [-f private.key] [-dsa dsa_priv.pem] update_file\n", fs.Name())
+		fmt.Fprintf(fs.Output(), "       %s verify -k public_key | -dsa dsa_pub.pem update_file signature\n", fs.Name())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	// Signing with DSA only is fine if no EdDSA key was supplied at all, but
+	// not if the supplied key can't be read.
+	key, err := privateKey(*keyFile)
+	if err != nil && (!errors.Is(err, errNoPrivateKey) || *dsaFile == "") {
+		return err
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var attrs []string
+
+	if *dsaFile != "" {
+		var b []byte
+		if b, err = os.ReadFile(*dsaFile); err != nil {
+			return err
+		}
+		var sig string
+		if sig, err = sign.SignDSA(string(b), f); err != nil {
+			return err
+		}
+		attrs = append(attrs, fmt.Sprintf("sparkle:dsaSignature=%q", sig))
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	if key != "" {
+		var sig string
+		if sig, _, err = sign.SignEdDSA(key, f); err != nil {
+			return err
+		}
+		attrs = append(attrs, fmt.Sprintf("sparkle:edSignature=%q", sig))
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	attrs = append(attrs, fmt.Sprintf("length=\"%d\"", fi.Size()))
+
+	fmt.Fprintln(w, strings.Join(attrs, " "))
+	return nil
+}
+
+func verifyCmd(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("winsparkle-sign verify", flag.ContinueOnError)
+	key := fs.String("k", "", "base64 encoded public key as passed to SetEdDSAPublicKey")
+	dsaFile := fs.String("dsa", "", "file to read the legacy DSA public key from")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s -k public_key | -dsa dsa_pub.pem update_file signature\n", fs.Name())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 2 || (*key == "") == (*dsaFile == "") {
+		fs.Usage()
+		return errUsage
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if *dsaFile != "" {
+		var b []byte
+		if b, err = os.ReadFile(*dsaFile); err != nil {
+			return err
+		}
+		err = sign.VerifyDSA(string(b), f, fs.Arg(1))
+	} else {
+		err = sign.VerifyEdDSA(*key, f, fs.Arg(1))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+
+	fmt.Fprintf(w, "%s: signature OK\n", fs.Arg(0))
+	return nil
+}
+
+var errNoPrivateKey = errors.New("no private key: use -f or set WINSPARKLE_PRIVATE_KEY")
+
+func privateKey(file string) (string, error) {
+	if file == "" {
+		if key := os.Getenv("WINSPARKLE_PRIVATE_KEY"); key != "" {
+			return key, nil
+		}
+		return "", errNoPrivateKey
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
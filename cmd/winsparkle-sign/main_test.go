@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/abemedia/go-winsparkle/sign"
+)
+
+func TestRun(t *testing.T) {
+	pub, priv, err := sign.GenerateEdDSAKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "private.key")
+	writeFile(t, keyFile, priv)
+	update := filepath.Join(dir, "verify") // Named like the subcommand.
+	writeFile(t, update, "hello\n")
+	dsaPriv := filepath.Join("..", "..", "sign", "testdata", "dsa_priv.pem")
+	dsaPub := filepath.Join("..", "..", "sign", "testdata", "dsa_pub.pem")
+
+	t.Setenv("WINSPARKLE_PRIVATE_KEY", "")
+
+	tests := []struct {
+		name  string
+		env   string
+		args  []string
+		attrs []string
+		err   bool
+	}{
+		{name: "key file", args: []string{"-f", keyFile, update}, attrs: []string{"edSignature", "length"}},
+		{name: "sign subcommand", args: []string{"sign", "-f", keyFile, update}, attrs: []string{"edSignature", "length"}},
+		{name: "double dash", args: []string{"-f", keyFile, "--", update}, attrs: []string{"edSignature", "length"}},
+		{name: "env", env: priv, args: []string{update}, attrs: []string{"edSignature", "length"}},
+		{name: "dsa", args: []string{"-f", keyFile, "-dsa", dsaPriv, update}, attrs: []string{"dsaSignature", "edSignature", "length"}},
+		{name: "dsa only", args: []string{"-dsa", dsaPriv, update}, attrs: []string{"dsaSignature", "length"}},
+		{name: "no key", args: []string{update}, err: true},
+		{name: "missing key file", args: []string{"-f", filepath.Join(dir, "missing"), "-dsa", dsaPriv, update}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("WINSPARKLE_PRIVATE_KEY", test.env)
+
+			var out bytes.Buffer
+			err := run(test.args, &out)
+			if test.err {
+				if err == nil {
+					t.Fatalf("should fail, got %q", out.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			attrs := regexp.MustCompile(`(?:sparkle:)?(\w+)="([^"]*)"`).FindAllStringSubmatch(out.String(), -1)
+			var names []string
+			values := map[string]string{}
+			for _, a := range attrs {
+				names = append(names, a[1])
+				values[a[1]] = a[2]
+			}
+			if strings.Join(names, " ") != strings.Join(test.attrs, " ") {
+				t.Fatalf("unexpected output: %q", out.String())
+			}
+
+			if sig, ok := values["edSignature"]; ok {
+				out.Reset()
+				if err = run([]string{"verify", "-k", pub, update, sig}, &out); err != nil {
+					t.Errorf("EdDSA signature should verify: %v", err)
+				}
+			}
+			if sig, ok := values["dsaSignature"]; ok {
+				out.Reset()
+				if err = run([]string{"verify", "-dsa", dsaPub, update, sig}, &out); err != nil {
+					t.Errorf("DSA signature should verify: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyMismatch(t *testing.T) {
+	pub, priv, err := sign.GenerateEdDSAKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := filepath.Join(t.TempDir(), "update.exe")
+	writeFile(t, update, "hello\n")
+	sig, _, err := sign.SignEdDSA(priv, strings.NewReader("other\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err = run([]string{"verify", "-k", pub, update, sig}, &out); err == nil {
+		t.Error("should fail")
+	}
+	if err = run([]string{"verify", update, sig}, &out); !errors.Is(err, errUsage) {
+		t.Errorf("should require a key: %v", err)
+	}
+}
+
+func writeFile(t *testing.T, name, data string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync/atomic"
+
+	"github.com/abemedia/go-winsparkle"
+)
+
+// ErrTampered is reported by [EncryptedStore] for values which can't be
+// decrypted, i.e. values which were modified, copied from another name or
+// written without the key.
+var ErrTampered = errors.New("config: value can't be decrypted")
+
+// KeyProvider returns the AES key used by [EncryptedStore], which must be 16,
+// 24 or 32 bytes long. It's called on every read and write.
+type KeyProvider func() ([]byte, error)
+
+// StaticKey returns a [KeyProvider] always returning key.
+func StaticKey(key []byte) KeyProvider {
+	return func() ([]byte, error) { return key, nil }
+}
+
+// EncryptedStore is a [winsparkle.ConfigStore] encrypting values with AES-GCM
+// before passing them to another store.
+//
+// Values are authenticated along with their name, so values which were
+// modified, copied from another name or written without the key are treated
+// as missing, making WinSparkle fall back to its defaults. This prevents e.g.
+// suppressing an update by writing a fake [KeySkipThisVersion], but tampering
+// can still reset a value to its default:
+//
+//   - a missing [KeyCheckForUpdates] disables automatic update checks,
+//   - a missing [KeyUpdateInterval] uses [DefaultUpdateInterval],
+//   - a missing [KeyLastCheckTime] checks for updates on the next start,
+//   - a missing [KeySkipThisVersion] skips no version and
+//   - a missing [KeyDidRunOnce] asks the user about automatic checks again.
+//
+// Use [EncryptedStore.SetErrorHandler] to detect this.
+type EncryptedStore struct {
+	store   winsparkle.ConfigStore
+	key     KeyProvider
+	handler atomic.Pointer[func(name string, err error)]
+}
+
+// NewEncryptedStore returns an [EncryptedStore] storing encrypted values in
+// store, using the key returned by key.
+func NewEncryptedStore(store winsparkle.ConfigStore, key KeyProvider) *EncryptedStore {
+	return &EncryptedStore{store: store, key: key}
+}
+
+// SetErrorHandler sets a function called with the name of a value and
+// [ErrTampered] if it can't be decrypted, or the error of the [KeyProvider].
+// It's also called with errors reported by WinSparkle through
+// [winsparkle.ConfigErrorHandler]. Pass nil to remove it.
+func (s *EncryptedStore) SetErrorHandler(fn func(name string, err error)) {
+	if fn == nil {
+		s.handler.Store(nil)
+		return
+	}
+	s.handler.Store(&fn)
+}
+
+// ConfigError implements [winsparkle.ConfigErrorHandler] by passing err to the
+// function set with [EncryptedStore.SetErrorHandler].
+func (s *EncryptedStore) ConfigError(name string, err error) {
+	if fn := s.handler.Load(); fn != nil {
+		(*fn)(name, err)
+	}
+}
+
+// Read returns a config value and a bool indicating if it was successful.
+// Values which can't be decrypted aren't returned.
+func (s *EncryptedStore) Read(name string) (string, bool) {
+	v, ok := s.store.Read(name)
+	if !ok {
+		return "", false
+	}
+	aead, err := s.aead()
+	if err != nil {
+		s.ConfigError(name, err)
+		return "", false
+	}
+	b, err := base64.StdEncoding.DecodeString(v)
+	if err != nil || len(b) < aead.NonceSize() {
+		s.ConfigError(name, ErrTampered)
+		return "", false
+	}
+	nonce, ciphertext := b[:aead.NonceSize()], b[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		s.ConfigError(name, ErrTampered)
+		return "", false
+	}
+	return string(plaintext), true
+}
+
+// Write a config value. Returns a bool indicating if it was successful.
+func (s *EncryptedStore) Write(name, value string) bool {
+	aead, err := s.aead()
+	if err != nil {
+		s.ConfigError(name, err)
+		return false
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return false
+	}
+	b := aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return s.store.Write(name, base64.StdEncoding.EncodeToString(b))
+}
+
+// Delete config value. Returns a bool indicating if it was successful.
+func (s *EncryptedStore) Delete(name string) bool {
+	return s.store.Delete(name)
+}
+
+func (s *EncryptedStore) aead() (cipher.AEAD, error) {
+	key, err := s.key()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.New("config: invalid key: " + err.Error())
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,101 @@
+package config_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/abemedia/go-winsparkle"
+	"github.com/abemedia/go-winsparkle/config"
+)
+
+var (
+	_ winsparkle.ConfigStore        = (*config.EncryptedStore)(nil)
+	_ winsparkle.ConfigErrorHandler = (*config.EncryptedStore)(nil)
+)
+
+func TestEncryptedStore(t *testing.T) {
+	key := config.StaticKey(bytes.Repeat([]byte{1}, 32))
+	backing := config.NewMemoryStore(nil)
+	s := config.NewEncryptedStore(backing, key)
+
+	if !s.Write(config.KeySkipThisVersion, "2.0") || !s.Write(config.KeyLastCheckTime, "1700000000") {
+		t.Fatal("failed to write")
+	}
+	if v, _ := backing.Read(config.KeySkipThisVersion); v == "" || v == "2.0" {
+		t.Errorf("value should be encrypted: %q", v)
+	}
+	if v, ok := s.Read(config.KeySkipThisVersion); !ok || v != "2.0" {
+		t.Errorf("unexpected value: %q, %t", v, ok)
+	}
+
+	// Value copied from another name.
+	v, _ := backing.Read(config.KeyLastCheckTime)
+	backing.Write(config.KeyUpdateInterval, v)
+	// Plain value.
+	backing.Write(config.KeyCheckForUpdates, "0")
+	// Modified value.
+	v, _ = backing.Read(config.KeySkipThisVersion)
+	if v[0] == 'A' {
+		backing.Write(config.KeySkipThisVersion, "B"+v[1:])
+	} else {
+		backing.Write(config.KeySkipThisVersion, "A"+v[1:])
+	}
+
+	var reported []string
+	s.SetErrorHandler(func(name string, err error) {
+		if !errors.Is(err, config.ErrTampered) {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		reported = append(reported, name)
+	})
+	tampered := []string{config.KeyUpdateInterval, config.KeyCheckForUpdates, config.KeySkipThisVersion}
+	for _, name := range tampered {
+		if got, ok := s.Read(name); ok {
+			t.Errorf("%s: should not read tampered value: %q", name, got)
+		}
+	}
+	if _, ok := s.Read(config.KeyDidRunOnce); ok {
+		t.Error("should not read missing value")
+	}
+	if !reflect.DeepEqual(reported, tampered) {
+		t.Errorf("unexpected reported values: %q", reported)
+	}
+	s.SetErrorHandler(nil)
+
+	other := config.NewEncryptedStore(backing, config.StaticKey(bytes.Repeat([]byte{2}, 32)))
+	if _, ok := other.Read(config.KeyLastCheckTime); ok {
+		t.Error("should not read value with wrong key")
+	}
+
+	if !s.Delete(config.KeyLastCheckTime) {
+		t.Error("failed to delete")
+	}
+	if _, ok := backing.Read(config.KeyLastCheckTime); ok {
+		t.Error("should delete value")
+	}
+}
+
+func TestEncryptedStoreInvalidKey(t *testing.T) {
+	tests := map[string]config.KeyProvider{
+		"short": config.StaticKey([]byte("short")),
+		"error": func() ([]byte, error) { return nil, errors.New("no key") },
+	}
+	for name, key := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := config.NewEncryptedStore(config.NewMemoryStore(map[string]string{"a": "b"}), key)
+			var errs int
+			s.SetErrorHandler(func(string, error) { errs++ })
+			if s.Write("a", "c") {
+				t.Error("should not write")
+			}
+			if _, ok := s.Read("a"); ok {
+				t.Error("should not read")
+			}
+			if errs != 2 {
+				t.Errorf("should report key error: %d", errs)
+			}
+		})
+	}
+}
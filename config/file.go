@@ -0,0 +1,174 @@
+// Package config provides implementations of [winsparkle.ConfigStore] for
+// storing WinSparkle's settings outside of the Windows Registry.
+//
+// Use them with [winsparkle.SetConfigMethods]. This package doesn't depend on
+// WinSparkle.dll and can be used on any platform.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore is a [winsparkle.ConfigStore] persisting values to a JSON file.
+//
+// Writes are atomic, i.e. the file is replaced rather than modified, and
+// access is synchronised across goroutines and processes using a lock file
+// next to the JSON file.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore returns a [FileStore] persisting values to the file at path.
+// The file and its directory are created on the first write.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// DefaultPath returns the default path for the settings of the application,
+// i.e. "<company>/<app>/WinSparkle.json" in the user's configuration
+// directory as returned by [os.UserConfigDir].
+func DefaultPath(company, app string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, company, app, "WinSparkle.json"), nil
+}
+
+// Path returns the path of the JSON file.
+func (s *FileStore) Path() string {
+	return s.path
+}
+
+// Read returns a config value and a bool indicating if it was successful.
+func (s *FileStore) Read(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(s.path); err != nil {
+		return "", false
+	}
+
+	var values map[string]string
+	err := s.withLock(false, func() error {
+		var err error
+		values, err = s.load()
+		return err
+	})
+	if err != nil {
+		return "", false
+	}
+
+	v, ok := values[name]
+	return v, ok
+}
+
+// Write a config value. Returns a bool indicating if it was successful.
+func (s *FileStore) Write(name, value string) bool {
+	return s.update(func(values map[string]string) bool {
+		values[name] = value
+		return true
+	})
+}
+
+// Delete config value. Returns a bool indicating if it was successful.
+func (s *FileStore) Delete(name string) bool {
+	return s.update(func(values map[string]string) bool {
+		if _, ok := values[name]; !ok {
+			return false
+		}
+		delete(values, name)
+		return true
+	})
+}
+
+// update applies fn to the stored values and saves them if fn returns true,
+// i.e. if it modified them.
+func (s *FileStore) update(fn func(map[string]string) bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return false
+	}
+
+	err := s.withLock(true, func() error {
+		values, err := s.load()
+		if err != nil {
+			return err
+		}
+		if !fn(values) {
+			return nil
+		}
+		return s.save(values)
+	})
+	return err == nil
+}
+
+// withLock calls fn while holding the lock file. Reads proceed without the
+// lock if it can't be created, e.g. for a policy file in a directory only
+// writable by administrators.
+func (s *FileStore) withLock(exclusive bool, fn func() error) error {
+	f, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if !exclusive && errors.Is(err, fs.ErrPermission) {
+		return fn()
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err = lockFile(f, exclusive); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+
+	return fn()
+}
+
+func (s *FileStore) load() (map[string]string, error) {
+	values := map[string]string{}
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (s *FileStore) save(values map[string]string) error {
+	b, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path)
+}
@@ -0,0 +1,83 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/abemedia/go-winsparkle"
+	"github.com/abemedia/go-winsparkle/config"
+)
+
+var _ winsparkle.ConfigStore = (*config.FileStore)(nil)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Company", "App", "WinSparkle.json")
+	s := config.NewFileStore(path)
+
+	if _, ok := s.Read("LastCheckTime"); ok {
+		t.Error("should not read missing value")
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Error("should not create file on read")
+	}
+
+	if !s.Write("LastCheckTime", "1700000000") || !s.Write("SkipThisVersion", "2.0") {
+		t.Fatal("should write")
+	}
+
+	// Values are persisted.
+	s = config.NewFileStore(path)
+	if v, ok := s.Read("LastCheckTime"); !ok || v != "1700000000" {
+		t.Errorf("unexpected value: %q, %t", v, ok)
+	}
+
+	if !s.Delete("SkipThisVersion") {
+		t.Error("should delete")
+	}
+	if _, ok := s.Read("SkipThisVersion"); ok {
+		t.Error("should not read deleted value")
+	}
+	if v, ok := s.Read("LastCheckTime"); !ok || v != "1700000000" {
+		t.Errorf("unexpected value: %q, %t", v, ok)
+	}
+}
+
+func TestFileStoreConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "WinSparkle.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Separate stores simulate separate processes sharing the file.
+			config.NewFileStore(path).Write("key"+strconv.Itoa(i), strconv.Itoa(i))
+		}(i)
+	}
+	wg.Wait()
+
+	s := config.NewFileStore(path)
+	for i := 0; i < 10; i++ {
+		if v, ok := s.Read("key" + strconv.Itoa(i)); !ok || v != strconv.Itoa(i) {
+			t.Errorf("key%d: unexpected value: %q, %t", i, v, ok)
+		}
+	}
+}
+
+func TestFileStoreInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "WinSparkle.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := config.NewFileStore(path)
+	if _, ok := s.Read("LastCheckTime"); ok {
+		t.Error("should not read from invalid file")
+	}
+	if s.Write("LastCheckTime", "1700000000") {
+		t.Error("should not overwrite invalid file")
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import "github.com/abemedia/go-winsparkle"
+
+// LayeredStore is a [winsparkle.ConfigStore] combining read-only policy
+// stores, e.g. a file deployed by an administrator, with a writable store
+// holding the user's settings.
+//
+// Values set by a policy take precedence over the user's values and are
+// locked, i.e. they can't be changed or deleted. This allows forcing settings
+// such as [KeyCheckForUpdates] or [KeyUpdateInterval].
+type LayeredStore struct {
+	writable winsparkle.ConfigStore
+	policies []winsparkle.ConfigStore
+}
+
+// NewLayeredStore returns a [LayeredStore] writing to writable. The policies
+// are only read and take precedence in the order given.
+func NewLayeredStore(writable winsparkle.ConfigStore, policies ...winsparkle.ConfigStore) *LayeredStore {
+	return &LayeredStore{writable: writable, policies: policies}
+}
+
+// Read returns a config value and a bool indicating if it was successful.
+func (s *LayeredStore) Read(name string) (string, bool) {
+	if v, ok := s.policy(name); ok {
+		return v, true
+	}
+	return s.writable.Read(name)
+}
+
+// Write a config value. Returns a bool indicating if it was successful.
+//
+// Writing a value locked by a policy fails unless it matches the policy's
+// value, in which case it succeeds without modifying the writable store.
+func (s *LayeredStore) Write(name, value string) bool {
+	if v, ok := s.policy(name); ok {
+		return v == value
+	}
+	return s.writable.Write(name, value)
+}
+
+// Delete config value. Returns a bool indicating if it was successful.
+// Deleting a value locked by a policy fails.
+func (s *LayeredStore) Delete(name string) bool {
+	if s.Locked(name) {
+		return false
+	}
+	return s.writable.Delete(name)
+}
+
+// Locked reports whether the value is set by a policy.
+func (s *LayeredStore) Locked(name string) bool {
+	_, ok := s.policy(name)
+	return ok
+}
+
+func (s *LayeredStore) policy(name string) (string, bool) {
+	for _, p := range s.policies {
+		if v, ok := p.Read(name); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
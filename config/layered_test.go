@@ -0,0 +1,65 @@
+package config_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/abemedia/go-winsparkle"
+	"github.com/abemedia/go-winsparkle/config"
+)
+
+var _ winsparkle.ConfigStore = (*config.LayeredStore)(nil)
+
+func TestLayeredStore(t *testing.T) {
+	user := config.NewMemoryStore(map[string]string{
+		config.KeyCheckForUpdates: "1",
+		config.KeyLastCheckTime:   "1700000000",
+	})
+	machine := config.NewMemoryStore(map[string]string{
+		config.KeyCheckForUpdates: "0",
+		config.KeyUpdateInterval:  "86400",
+	})
+	domain := config.NewMemoryStore(map[string]string{
+		config.KeyUpdateInterval: "604800",
+	})
+	s := config.NewLayeredStore(user, domain, machine)
+
+	reads := map[string]string{
+		config.KeyCheckForUpdates: "0",
+		config.KeyUpdateInterval:  "604800",
+		config.KeyLastCheckTime:   "1700000000",
+	}
+	for name, want := range reads {
+		if v, ok := s.Read(name); !ok || v != want {
+			t.Errorf("%s: got %q, %t want %q", name, v, ok, want)
+		}
+	}
+
+	if !s.Locked(config.KeyCheckForUpdates) || s.Locked(config.KeyLastCheckTime) {
+		t.Error("unexpected locked values")
+	}
+
+	if s.Write(config.KeyCheckForUpdates, "1") {
+		t.Error("should not write locked value")
+	}
+	if !s.Write(config.KeyCheckForUpdates, "0") {
+		t.Error("should accept locked value matching policy")
+	}
+	if s.Delete(config.KeyUpdateInterval) {
+		t.Error("should not delete locked value")
+	}
+	if !s.Write(config.KeySkipThisVersion, "2.0") || !s.Delete(config.KeyLastCheckTime) {
+		t.Error("should modify unlocked values")
+	}
+
+	want := map[string]string{
+		config.KeyCheckForUpdates: "1",
+		config.KeySkipThisVersion: "2.0",
+	}
+	if got := user.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected user values: %v", got)
+	}
+	if got := machine.Snapshot(); len(got) != 2 {
+		t.Errorf("policy should not be modified: %v", got)
+	}
+}
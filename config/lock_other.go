@@ -0,0 +1,12 @@
+//go:build !unix && !windows
+
+package config
+
+import "os"
+
+// File locking isn't supported on this platform, so FileStore is only safe
+// for use by a single process.
+
+func lockFile(*os.File, bool) error { return nil }
+
+func unlockFile(*os.File) error { return nil }
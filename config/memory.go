@@ -0,0 +1,157 @@
+package config
+
+import (
+	"sort"
+	"sync"
+)
+
+// Change describes a modification of a [MemoryStore].
+type Change struct {
+	Name    string
+	Value   string
+	Deleted bool
+}
+
+// MemoryStore is a [winsparkle.ConfigStore] keeping values in memory.
+//
+// It is safe for concurrent use, which is required as WinSparkle calls the
+// config methods from its own threads. The zero value is an empty store ready
+// to use.
+type MemoryStore struct {
+	mu        sync.Mutex
+	values    map[string]string
+	listeners map[int]func(Change)
+	nextID    int
+}
+
+// NewMemoryStore returns a [MemoryStore] containing a copy of values.
+func NewMemoryStore(values map[string]string) *MemoryStore {
+	return &MemoryStore{values: copyMap(values)}
+}
+
+// Read returns a config value and a bool indicating if it was successful.
+func (s *MemoryStore) Read(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[name]
+	return v, ok
+}
+
+// Write a config value. Returns a bool indicating if it was successful.
+func (s *MemoryStore) Write(name, value string) bool {
+	s.mu.Lock()
+	if s.values == nil {
+		s.values = map[string]string{}
+	}
+	old, ok := s.values[name]
+	s.values[name] = value
+	listeners := s.listenersLocked()
+	s.mu.Unlock()
+
+	if !ok || old != value {
+		notify(listeners, Change{Name: name, Value: value})
+	}
+	return true
+}
+
+// Delete config value. Returns a bool indicating if it was successful.
+func (s *MemoryStore) Delete(name string) bool {
+	s.mu.Lock()
+	_, ok := s.values[name]
+	delete(s.values, name)
+	listeners := s.listenersLocked()
+	s.mu.Unlock()
+
+	if ok {
+		notify(listeners, Change{Name: name, Deleted: true})
+	}
+	return true
+}
+
+// Snapshot returns a copy of all values.
+func (s *MemoryStore) Snapshot() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyMap(s.values)
+}
+
+// Restore replaces all values with a copy of values, e.g. as returned by
+// [MemoryStore.Snapshot]. Listeners are notified of every changed value in
+// order of their names.
+func (s *MemoryStore) Restore(values map[string]string) {
+	s.mu.Lock()
+	old := s.values
+	s.values = copyMap(values)
+	listeners := s.listenersLocked()
+	s.mu.Unlock()
+
+	var changes []Change
+	for k, v := range values {
+		if o, ok := old[k]; !ok || o != v {
+			changes = append(changes, Change{Name: k, Value: v})
+		}
+	}
+	for k := range old {
+		if _, ok := values[k]; !ok {
+			changes = append(changes, Change{Name: k, Deleted: true})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+
+	for _, c := range changes {
+		notify(listeners, c)
+	}
+}
+
+// Subscribe registers fn to be called after a value was written or deleted.
+// It's not called if a value is written without changing it.
+//
+// The function is called synchronously on the goroutine or thread modifying
+// the store, so it must not block. Call the returned function to unsubscribe.
+func (s *MemoryStore) Subscribe(fn func(Change)) (unsubscribe func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listeners == nil {
+		s.listeners = map[int]func(Change){}
+	}
+	id := s.nextID
+	s.nextID++
+	s.listeners[id] = fn
+
+	return func() {
+		s.mu.Lock()
+		delete(s.listeners, id)
+		s.mu.Unlock()
+	}
+}
+
+func (s *MemoryStore) listenersLocked() []func(Change) {
+	if len(s.listeners) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(s.listeners))
+	for id := range s.listeners {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	fns := make([]func(Change), len(ids))
+	for i, id := range ids {
+		fns[i] = s.listeners[id]
+	}
+	return fns
+}
+
+func notify(listeners []func(Change), c Change) {
+	for _, fn := range listeners {
+		fn(c)
+	}
+}
+
+func copyMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
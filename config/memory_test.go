@@ -0,0 +1,79 @@
+package config_test
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/abemedia/go-winsparkle"
+	"github.com/abemedia/go-winsparkle/config"
+)
+
+var _ winsparkle.ConfigStore = (*config.MemoryStore)(nil)
+
+func TestMemoryStore(t *testing.T) {
+	var s config.MemoryStore
+
+	var changes []config.Change
+	unsubscribe := s.Subscribe(func(c config.Change) { changes = append(changes, c) })
+
+	s.Write("LastCheckTime", "1700000000")
+	s.Write("LastCheckTime", "1700000000") // Unchanged.
+	s.Write("SkipThisVersion", "2.0")
+	s.Delete("SkipThisVersion")
+	s.Delete("SkipThisVersion") // Already deleted.
+
+	if v, ok := s.Read("LastCheckTime"); !ok || v != "1700000000" {
+		t.Errorf("unexpected value: %q, %t", v, ok)
+	}
+	if _, ok := s.Read("SkipThisVersion"); ok {
+		t.Error("should not read deleted value")
+	}
+
+	snapshot := s.Snapshot()
+	if want := map[string]string{"LastCheckTime": "1700000000"}; !reflect.DeepEqual(snapshot, want) {
+		t.Errorf("unexpected snapshot: %v", snapshot)
+	}
+	s.Write("CheckForUpdates", "1")
+	s.Restore(snapshot)
+	if got := s.Snapshot(); !reflect.DeepEqual(got, snapshot) {
+		t.Errorf("unexpected values after restore: %v", got)
+	}
+
+	unsubscribe()
+	s.Write("UpdateInterval", "3600")
+
+	want := []config.Change{
+		{Name: "LastCheckTime", Value: "1700000000"},
+		{Name: "SkipThisVersion", Value: "2.0"},
+		{Name: "SkipThisVersion", Deleted: true},
+		{Name: "CheckForUpdates", Value: "1"},
+		{Name: "CheckForUpdates", Deleted: true},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes %+v\nwant %+v", changes, want)
+	}
+}
+
+func TestMemoryStoreConcurrent(t *testing.T) {
+	s := config.NewMemoryStore(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			unsubscribe := s.Subscribe(func(config.Change) {})
+			s.Write(strconv.Itoa(i), strconv.Itoa(i))
+			s.Read(strconv.Itoa(i))
+			s.Snapshot()
+			unsubscribe()
+		}(i)
+	}
+	wg.Wait()
+
+	if n := len(s.Snapshot()); n != 10 {
+		t.Errorf("expected 10 values, got %d", n)
+	}
+}
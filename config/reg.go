@@ -0,0 +1,222 @@
+package config
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/abemedia/go-winsparkle"
+)
+
+// RegistryKey returns the registry key WinSparkle stores its settings in by
+// default, i.e. "HKEY_CURRENT_USER\Software\<company>\<app>\WinSparkle".
+func RegistryKey(company, app string) string {
+	return `HKEY_CURRENT_USER\Software\` + company + `\` + app + `\WinSparkle`
+}
+
+// ImportReg reads a registry export (.reg file) as created by regedit and
+// writes the values of key into store. This allows migrating the settings of
+// existing users to a store passed to [winsparkle.SetConfigMethods].
+//
+// The key is either a full key such as returned by [RegistryKey] or a path
+// relative to HKEY_CURRENT_USER as passed to [winsparkle.SetRegistryPath].
+// Values of subkeys are ignored.
+//
+// String values are imported as is. DWORD and QWORD values are converted to
+// decimal strings. Deleted values ("name"=-) are deleted from the store.
+// Binary and multi-string values aren't used by WinSparkle and are skipped.
+func ImportReg(r io.Reader, key string, store winsparkle.ConfigStore) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	key = normalizeKey(key)
+	inKey := false
+
+	lines := regLines(decodeReg(b))
+	for i, line := range lines {
+		if strings.HasPrefix(line, "[") {
+			section := strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			inKey = normalizeKey(section) == key
+			continue
+		}
+		if !inKey {
+			continue
+		}
+
+		name, value, deleted, ok, err := parseRegValue(line)
+		if err != nil {
+			return fmt.Errorf("config: invalid .reg line %d: %w", i+1, err)
+		}
+		if !ok {
+			continue
+		}
+
+		if deleted {
+			if _, exists := store.Read(name); exists && !store.Delete(name) {
+				return errors.New("config: failed to delete " + name)
+			}
+		} else if !store.Write(name, value) {
+			return errors.New("config: failed to write " + name)
+		}
+	}
+
+	return nil
+}
+
+var rootKeys = map[string]string{
+	"HKCU": "HKEY_CURRENT_USER",
+	"HKLM": "HKEY_LOCAL_MACHINE",
+	"HKCR": "HKEY_CLASSES_ROOT",
+	"HKU":  "HKEY_USERS",
+}
+
+// normalizeKey returns the upper case full key with abbreviated root keys
+// expanded.
+func normalizeKey(key string) string {
+	key = strings.ToUpper(strings.Trim(strings.TrimSpace(key), `\`))
+	root, rest, _ := strings.Cut(key, `\`)
+	if full, ok := rootKeys[root]; ok {
+		root = full
+	} else if !strings.HasPrefix(root, "HKEY_") {
+		return "HKEY_CURRENT_USER\\" + key
+	}
+	if rest == "" {
+		return root
+	}
+	return root + `\` + rest
+}
+
+// decodeReg returns the contents of a .reg file, which regedit writes as
+// UTF-16LE in version 5 and as ANSI in version 4.
+func decodeReg(b []byte) string {
+	switch {
+	case bytes.HasPrefix(b, []byte{0xff, 0xfe}):
+		return decodeUTF16(b[2:])
+	case bytes.HasPrefix(b, []byte{0xef, 0xbb, 0xbf}):
+		return string(b[3:])
+	default:
+		return string(b)
+	}
+}
+
+func decodeUTF16(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u))
+}
+
+// regLines returns the non-empty lines of a .reg file with continuation lines
+// joined and comments removed.
+func regLines(s string) []string {
+	var lines []string
+	var cont strings.Builder
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if cont.Len() == 0 && (line == "" || strings.HasPrefix(line, ";")) {
+			continue
+		}
+		if strings.HasSuffix(line, `\`) && !strings.HasPrefix(line, "[") {
+			cont.WriteString(strings.TrimSuffix(line, `\`))
+			continue
+		}
+		cont.WriteString(line)
+		lines = append(lines, cont.String())
+		cont.Reset()
+	}
+	if cont.Len() > 0 {
+		lines = append(lines, cont.String())
+	}
+	return lines
+}
+
+// parseRegValue parses a value line. It returns ok false for the file header
+// and values which aren't imported.
+func parseRegValue(line string) (name, value string, deleted, ok bool, err error) {
+	switch {
+	case strings.HasPrefix(line, "@="):
+		return "", "", false, false, nil // Default value, not used by WinSparkle.
+	case !strings.HasPrefix(line, `"`):
+		return "", "", false, false, nil // Header, e.g. "Windows Registry Editor Version 5.00".
+	}
+
+	name, rest, err := unquote(line)
+	if err != nil {
+		return "", "", false, false, err
+	}
+	data, found := strings.CutPrefix(strings.TrimSpace(rest), "=")
+	if !found {
+		return "", "", false, false, errors.New("missing '='")
+	}
+	data = strings.TrimSpace(data)
+
+	switch {
+	case data == "-":
+		return name, "", true, true, nil
+
+	case strings.HasPrefix(data, `"`):
+		value, _, err = unquote(data)
+		return name, value, false, err == nil, err
+
+	case strings.HasPrefix(data, "dword:"):
+		var i uint64
+		if i, err = strconv.ParseUint(strings.TrimPrefix(data, "dword:"), 16, 32); err != nil {
+			return "", "", false, false, err
+		}
+		return name, strconv.FormatUint(i, 10), false, true, nil
+
+	case strings.HasPrefix(data, "hex(b):"):
+		var b []byte
+		if b, err = parseHex(strings.TrimPrefix(data, "hex(b):")); err != nil {
+			return "", "", false, false, err
+		}
+		if len(b) != 8 {
+			return "", "", false, false, errors.New("invalid QWORD value")
+		}
+		return name, strconv.FormatUint(binary.LittleEndian.Uint64(b), 10), false, true, nil
+
+	case strings.HasPrefix(data, "hex(1):"), strings.HasPrefix(data, "hex(2):"):
+		var b []byte
+		if b, err = parseHex(data[len("hex(1):"):]); err != nil {
+			return "", "", false, false, err
+		}
+		return name, strings.TrimRight(decodeUTF16(b), "\x00"), false, true, nil
+
+	default:
+		return "", "", false, false, nil
+	}
+}
+
+// unquote parses a quoted .reg string at the start of s and returns it along
+// with the remainder of s.
+func unquote(s string) (string, string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 == len(s) {
+				return "", "", errors.New("unterminated string")
+			}
+			i++
+			b.WriteByte(s[i])
+		case '"':
+			return b.String(), s[i+1:], nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", errors.New("unterminated string")
+}
+
+func parseHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.NewReplacer(",", "", " ", "").Replace(s))
+}
@@ -0,0 +1,81 @@
+package config_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/abemedia/go-winsparkle/config"
+)
+
+const regExport = `Windows Registry Editor Version 5.00
+
+[HKEY_CURRENT_USER\Software\My Company\My App]
+"Theme"="dark"
+
+[HKEY_CURRENT_USER\Software\My Company\My App\WinSparkle]
+"CheckForUpdates"="1"
+"LastCheckTime"="1700000000"
+"SkipThisVersion"="2.0 \"beta\""
+"UpdateInterval"=dword:00000e10
+"DidRunOnce"=hex(2):31,00,\
+  00,00
+"Binary"=hex:01,02
+"Obsolete"=-
+@="default"
+
+[HKEY_CURRENT_USER\Software\My Company\My App\WinSparkle\Sub]
+"CheckForUpdates"="0"
+`
+
+func TestImportReg(t *testing.T) {
+	want := map[string]string{
+		"CheckForUpdates": "1",
+		"LastCheckTime":   "1700000000",
+		"SkipThisVersion": `2.0 "beta"`,
+		"UpdateInterval":  "3600",
+		"DidRunOnce":      "1",
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		data []byte
+	}{
+		{"UTF-8", config.RegistryKey("My Company", "My App"), []byte(regExport)},
+		{"UTF-16", config.RegistryKey("My Company", "My App"), encodeUTF16(regExport)},
+		{"CRLF", config.RegistryKey("My Company", "My App"), []byte(strings.ReplaceAll(regExport, "\n", "\r\n"))},
+		{"abbreviated", `HKCU\Software\My Company\My App\WinSparkle`, []byte(regExport)},
+		{"registry path", `software\my company\my app\winsparkle`, []byte(regExport)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := config.NewMemoryStore(map[string]string{"Obsolete": "1"})
+			if err := config.ImportReg(bytes.NewReader(test.data), test.key, store); err != nil {
+				t.Fatal(err)
+			}
+			if got := store.Snapshot(); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v\nwant %v", got, want)
+			}
+		})
+	}
+}
+
+func TestImportRegInvalid(t *testing.T) {
+	data := "[HKEY_CURRENT_USER\\Software\\A\\B\\WinSparkle]\n\"LastCheckTime\"=dword:nope\n"
+	err := config.ImportReg(strings.NewReader(data), config.RegistryKey("A", "B"), config.NewMemoryStore(nil))
+	if err == nil {
+		t.Error("should fail")
+	}
+}
+
+func encodeUTF16(s string) []byte {
+	b := []byte{0xff, 0xfe}
+	for _, c := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(c), byte(c>>8))
+	}
+	return b
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/abemedia/go-winsparkle"
+)
+
+// Names of the values WinSparkle stores.
+const (
+	// KeyCheckForUpdates stores whether updates are checked automatically as
+	// "1" or "0".
+	KeyCheckForUpdates = "CheckForUpdates"
+
+	// KeyUpdateInterval stores the interval between automatic update checks
+	// in seconds.
+	KeyUpdateInterval = "UpdateInterval"
+
+	// KeyLastCheckTime stores the time of the last update check as seconds
+	// since the Unix epoch.
+	KeyLastCheckTime = "LastCheckTime"
+
+	// KeySkipThisVersion stores the version the user chose to skip.
+	KeySkipThisVersion = "SkipThisVersion"
+
+	// KeyDidRunOnce stores whether WinSparkle ran before as "1" or "0". On the
+	// first run WinSparkle asks the user whether to check for updates
+	// automatically.
+	KeyDidRunOnce = "DidRunOnce"
+)
+
+// Keys contains the names of all values WinSparkle stores.
+var Keys = []string{
+	KeyCheckForUpdates,
+	KeyUpdateInterval,
+	KeyLastCheckTime,
+	KeySkipThisVersion,
+	KeyDidRunOnce,
+}
+
+const (
+	// DefaultUpdateInterval is the interval between automatic update checks
+	// used by WinSparkle if none is stored.
+	DefaultUpdateInterval = 24 * time.Hour
+
+	// MinUpdateInterval is the minimum interval between automatic update
+	// checks accepted by WinSparkle.
+	MinUpdateInterval = time.Hour
+)
+
+// Settings provides typed access to the values WinSparkle stores in a
+// [winsparkle.ConfigStore], using the same encoding as WinSparkle.
+//
+// Getters return WinSparkle's defaults for missing or invalid values.
+type Settings struct {
+	store winsparkle.ConfigStore
+}
+
+// NewSettings returns [Settings] reading from and writing to store.
+func NewSettings(store winsparkle.ConfigStore) *Settings {
+	return &Settings{store: store}
+}
+
+// AutomaticCheckForUpdates returns whether updates are checked automatically.
+// Defaults to false.
+func (s *Settings) AutomaticCheckForUpdates() bool {
+	return s.bool(KeyCheckForUpdates)
+}
+
+// SetAutomaticCheckForUpdates sets whether updates are checked automatically.
+func (s *Settings) SetAutomaticCheckForUpdates(check bool) error {
+	return s.setBool(KeyCheckForUpdates, check)
+}
+
+// UpdateCheckInterval returns the interval between automatic update checks.
+// Defaults to [DefaultUpdateInterval].
+func (s *Settings) UpdateCheckInterval() time.Duration {
+	i, ok := s.int(KeyUpdateInterval)
+	if !ok {
+		return DefaultUpdateInterval
+	}
+	return time.Duration(i) * time.Second
+}
+
+// SetUpdateCheckInterval sets the interval between automatic update checks.
+// Like WinSparkle, intervals below [MinUpdateInterval] are raised to it.
+func (s *Settings) SetUpdateCheckInterval(interval time.Duration) error {
+	if interval < MinUpdateInterval {
+		interval = MinUpdateInterval
+	}
+	return s.write(KeyUpdateInterval, strconv.FormatInt(int64(interval/time.Second), 10))
+}
+
+// LastCheckTime returns the time of the last update check. Defaults to the
+// zero time, indicating that the update check has never run.
+func (s *Settings) LastCheckTime() time.Time {
+	i, ok := s.int(KeyLastCheckTime)
+	if !ok || i == -1 {
+		return time.Time{}
+	}
+	return time.Unix(i, 0)
+}
+
+// SetLastCheckTime sets the time of the last update check. The zero time
+// deletes it, causing the next automatic check to run on startup.
+func (s *Settings) SetLastCheckTime(t time.Time) error {
+	if t.IsZero() {
+		return s.delete(KeyLastCheckTime)
+	}
+	return s.write(KeyLastCheckTime, strconv.FormatInt(t.Unix(), 10))
+}
+
+// SkippedVersion returns the version the user chose to skip or an empty
+// string if there is none.
+func (s *Settings) SkippedVersion() string {
+	v, _ := s.store.Read(KeySkipThisVersion)
+	return v
+}
+
+// SetSkippedVersion sets the version to skip. An empty string deletes it.
+func (s *Settings) SetSkippedVersion(version string) error {
+	if version == "" {
+		return s.delete(KeySkipThisVersion)
+	}
+	return s.write(KeySkipThisVersion, version)
+}
+
+// DidRunOnce returns whether WinSparkle ran before. Defaults to false.
+func (s *Settings) DidRunOnce() bool {
+	return s.bool(KeyDidRunOnce)
+}
+
+// SetDidRunOnce sets whether WinSparkle ran before.
+func (s *Settings) SetDidRunOnce(didRun bool) error {
+	return s.setBool(KeyDidRunOnce, didRun)
+}
+
+// Reset deletes all values, restoring WinSparkle's defaults.
+func (s *Settings) Reset() error {
+	var errs []error
+	for _, key := range Keys {
+		if err := s.delete(key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Settings) bool(name string) bool {
+	i, ok := s.int(name)
+	return ok && i != 0
+}
+
+func (s *Settings) setBool(name string, b bool) error {
+	if b {
+		return s.write(name, "1")
+	}
+	return s.write(name, "0")
+}
+
+func (s *Settings) int(name string) (int64, bool) {
+	v, ok := s.store.Read(name)
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	return i, err == nil
+}
+
+func (s *Settings) write(name, value string) error {
+	if !s.store.Write(name, value) {
+		return errors.New("config: failed to write " + name)
+	}
+	return nil
+}
+
+func (s *Settings) delete(name string) error {
+	if _, ok := s.store.Read(name); !ok {
+		return nil
+	}
+	if !s.store.Delete(name) {
+		return errors.New("config: failed to delete " + name)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package config_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/abemedia/go-winsparkle/config"
+)
+
+func TestSettings(t *testing.T) {
+	store := config.NewMemoryStore(nil)
+	s := config.NewSettings(store)
+
+	// Defaults.
+	if s.AutomaticCheckForUpdates() || s.DidRunOnce() {
+		t.Error("should default to false")
+	}
+	if i := s.UpdateCheckInterval(); i != config.DefaultUpdateInterval {
+		t.Errorf("unexpected default interval: %s", i)
+	}
+	if !s.LastCheckTime().IsZero() || s.SkippedVersion() != "" {
+		t.Error("should default to zero value")
+	}
+
+	last := time.Unix(1700000000, 0)
+	for _, err := range []error{
+		s.SetAutomaticCheckForUpdates(true),
+		s.SetUpdateCheckInterval(time.Minute),
+		s.SetLastCheckTime(last),
+		s.SetSkippedVersion("2.0"),
+		s.SetDidRunOnce(true),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]string{
+		"CheckForUpdates": "1",
+		"UpdateInterval":  "3600",
+		"LastCheckTime":   "1700000000",
+		"SkipThisVersion": "2.0",
+		"DidRunOnce":      "1",
+	}
+	if got := store.Snapshot(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected stored values: %v", got)
+	}
+
+	if !s.AutomaticCheckForUpdates() || !s.DidRunOnce() {
+		t.Error("should be true")
+	}
+	if i := s.UpdateCheckInterval(); i != time.Hour {
+		t.Errorf("unexpected interval: %s", i)
+	}
+	if !s.LastCheckTime().Equal(last) {
+		t.Errorf("unexpected last check time: %s", s.LastCheckTime())
+	}
+	if v := s.SkippedVersion(); v != "2.0" {
+		t.Errorf("unexpected skipped version: %q", v)
+	}
+
+	if err := s.SetSkippedVersion(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.Read("SkipThisVersion"); ok {
+		t.Error("should delete skipped version")
+	}
+
+	if err := s.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.Snapshot(); len(got) != 0 {
+		t.Errorf("should delete all values: %v", got)
+	}
+}
+
+func TestSettingsInvalid(t *testing.T) {
+	s := config.NewSettings(config.NewMemoryStore(map[string]string{
+		"CheckForUpdates": "yes",
+		"UpdateInterval":  "daily",
+		"LastCheckTime":   "-1",
+	}))
+
+	if s.AutomaticCheckForUpdates() {
+		t.Error("should default to false")
+	}
+	if i := s.UpdateCheckInterval(); i != config.DefaultUpdateInterval {
+		t.Errorf("unexpected interval: %s", i)
+	}
+	if !s.LastCheckTime().IsZero() {
+		t.Error("should default to zero time")
+	}
+}
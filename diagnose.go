@@ -0,0 +1,328 @@
+package winsparkle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abemedia/go-winsparkle/appcast"
+	"github.com/abemedia/go-winsparkle/internal/feed"
+	"github.com/abemedia/go-winsparkle/sign"
+)
+
+// WinSparkle only reports that an error occurred. To find its cause, the
+// settings passed to WinSparkle and the progress of the current update check
+// are recorded. The appcast is fetched alongside WinSparkle when a manual
+// check starts, or once WinSparkle reports an error otherwise, and the update
+// is downloaded again when WinSparkle reports an error after finding it.
+
+// diagTimeout limits the requests made to diagnose an error.
+const diagTimeout = 10 * time.Second
+
+// diagMaxDownload is the size of the largest update downloaded to verify its
+// signature, as EdDSA signatures are verified in memory.
+var diagMaxDownload int64 = 256 << 20
+
+// diagState is the information used to diagnose an error.
+type diagState struct {
+	appcastURL     string
+	app            string
+	version        string
+	edDSAPublicKey string
+	dsaPublicKey   string
+	headers        [][2]string
+	os             string
+
+	// foundUpdate is set once WinSparkle found an update, i.e. the appcast
+	// was fetched and errors are caused by the download or the installer.
+	foundUpdate bool
+
+	// installerErr is the error returned by a run-installer handler.
+	installerErr error
+
+	// appcast is the appcast fetched when the check started, if any.
+	appcast *appcastFetch
+}
+
+// appcastFetch is the result of fetching the appcast in the background.
+type appcastFetch struct {
+	done chan struct{}
+	a    *appcast.Appcast
+	err  *UpdateError
+}
+
+type diagnostics struct {
+	mu      sync.Mutex
+	state   diagState
+	handler func(err error)
+
+	// ctx is cancelled by [Cleanup] to stop pending requests.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+var (
+	diag = diagnostics{state: diagState{os: sparkleOS[runtime.GOARCH]}}
+
+	// diagClient is used to repeat WinSparkle's requests.
+	diagClient = &http.Client{}
+)
+
+// sparkleOS maps GOARCH to the value of sparkle:os WinSparkle accepts in
+// addition to "windows".
+var sparkleOS = map[string]string{
+	"386":   "windows-x86",
+	"amd64": "windows-x64",
+	"arm64": "windows-arm64",
+}
+
+// set calls fn to record a setting.
+func (d *diagnostics) set(fn func(s *diagState)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	fn(&d.state)
+}
+
+// setHandler sets the function called with diagnosed errors. While it's set
+// the did-find callback is registered to tell apart errors before and after
+// finding an update.
+func (d *diagnostics) setHandler(fn func(err error)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if (fn != nil) != (d.handler != nil) {
+		if fn != nil {
+			_ = eventHooks[EventDidFindUpdate].register() // Ignore callbacks missing from older DLLs.
+		} else {
+			_ = eventHooks[EventDidFindUpdate].unregister()
+		}
+	}
+	d.handler = fn
+}
+
+func (d *diagnostics) errorHandler() func(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.handler
+}
+
+// wantedLocked reports whether errors are diagnosed.
+func (d *diagnostics) wantedLocked() bool {
+	return d.handler != nil || eventHub.Len() > 0
+}
+
+// contextLocked returns a context for the requests of a diagnosis.
+func (d *diagnostics) contextLocked() (context.Context, context.CancelFunc) {
+	if d.ctx == nil {
+		d.ctx, d.cancel = context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(d.ctx, diagTimeout)
+}
+
+// start resets the progress when a manual update check starts and fetches
+// the appcast if errors are diagnosed. It isn't called by [Init] as WinSparkle
+// only checks for updates on startup if they're due.
+func (d *diagnostics) start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.state.foundUpdate, d.state.installerErr, d.state.appcast = false, nil, nil
+	if d.state.appcastURL == "" || !d.wantedLocked() {
+		return
+	}
+
+	s := d.state
+	f := &appcastFetch{done: make(chan struct{})}
+	d.state.appcast = f
+	ctx, cancel := d.contextLocked()
+	go func() {
+		defer cancel()
+		defer close(f.done)
+		f.a, f.err = s.fetchAppcast(ctx, diagClient)
+	}()
+}
+
+// stop cancels pending requests.
+func (d *diagnostics) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		d.cancel()
+		d.ctx, d.cancel = nil, nil
+	}
+	d.state.appcast = nil
+}
+
+// installerFailed records the error returned by a run-installer handler.
+func (d *diagnostics) installerFailed(err error) {
+	d.set(func(s *diagState) { s.installerErr = err })
+}
+
+// observe records the progress of the update check and returns the state
+// before the event. Any event other than [EventDidFindUpdate] ends the check.
+func (d *diagnostics) observe(kind EventKind) diagState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.state
+	if kind == EventDidFindUpdate {
+		d.state.foundUpdate = true
+	} else {
+		d.state.foundUpdate, d.state.installerErr, d.state.appcast = false, nil, nil
+	}
+	return s
+}
+
+// diagnose returns the cause of the error WinSparkle reported in state s.
+func (d *diagnostics) diagnose(s *diagState) error {
+	d.mu.Lock()
+	ctx, cancel := d.contextLocked()
+	d.mu.Unlock()
+	defer cancel()
+	return s.diagnose(ctx, diagClient)
+}
+
+// diagnose finds the cause of an error from the appcast and by downloading
+// the update again. It can only guess: if the requests succeed, or fail in
+// a way which doesn't tell anything about WinSparkle's requests, the cause
+// is unknown.
+func (s *diagState) diagnose(ctx context.Context, client *http.Client) *UpdateError {
+	if s.installerErr != nil {
+		return &UpdateError{Category: ErrorInstaller, Err: s.installerErr}
+	}
+	if s.appcastURL == "" {
+		// The URL is read from the application's resources.
+		return &UpdateError{Category: ErrorUnknown}
+	}
+
+	var (
+		a   *appcast.Appcast
+		err *UpdateError
+	)
+	if s.appcast != nil {
+		select {
+		case <-s.appcast.done:
+			a, err = s.appcast.a, s.appcast.err
+		case <-ctx.Done():
+			return &UpdateError{Category: ErrorUnknown, Err: ctx.Err()}
+		}
+	} else {
+		a, err = s.fetchAppcast(ctx, client)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !s.foundUpdate {
+		return &UpdateError{Category: ErrorUnknown}
+	}
+	_, enc := feed.Latest(a, s.os)
+	if enc == nil {
+		return &UpdateError{Category: ErrorUnknown}
+	}
+	return s.verify(ctx, client, enc)
+}
+
+func (s *diagState) fetchAppcast(ctx context.Context, client *http.Client) (*appcast.Appcast, *UpdateError) {
+	a, err := s.feedClient(client).Appcast(ctx, s.appcastURL)
+	if err != nil {
+		var (
+			statusErr *feed.StatusError
+			parseErr  *feed.ParseError
+		)
+		switch {
+		case errors.As(err, &statusErr):
+			return nil, &UpdateError{Category: ErrorHTTPStatus, StatusCode: statusErr.StatusCode, Err: err}
+		case errors.As(err, &parseErr):
+			return nil, &UpdateError{Category: ErrorAppcast, Err: parseErr.Err}
+		default:
+			return nil, requestError(ctx, ErrorNetwork, err)
+		}
+	}
+	return a, nil
+}
+
+// verify downloads the update and verifies its signature. The download is
+// skipped if its size doesn't match the appcast or exceeds diagMaxDownload.
+func (s *diagState) verify(ctx context.Context, client *http.Client, enc *appcast.Enclosure) *UpdateError {
+	switch {
+	case s.edDSAPublicKey != "" && enc.EdSignature == "":
+		return &UpdateError{Category: ErrorSignature, Err: errors.New("update is not signed with EdDSA")}
+	case s.edDSAPublicKey == "" && s.dsaPublicKey != "" && enc.DSASignature == "":
+		return &UpdateError{Category: ErrorSignature, Err: errors.New("update is not signed with DSA")}
+	}
+
+	resp, err := s.feedClient(client).Get(ctx, enc.URL, false)
+	if err != nil {
+		var statusErr *feed.StatusError
+		if errors.As(err, &statusErr) {
+			return &UpdateError{Category: ErrorDownload, StatusCode: statusErr.StatusCode, Err: err}
+		}
+		return requestError(ctx, ErrorDownload, err)
+	}
+	defer resp.Body.Close()
+
+	// An update of a different size than the appcast says can't match the
+	// signature, unless the appcast's length is wrong.
+	if enc.Length > 0 && resp.ContentLength >= 0 && resp.ContentLength != enc.Length {
+		return &UpdateError{Category: ErrorSignature, Err: errors.New("update is " +
+			strconv.FormatInt(resp.ContentLength, 10) + " bytes instead of " + strconv.FormatInt(enc.Length, 10))}
+	}
+	if resp.ContentLength > diagMaxDownload {
+		return &UpdateError{Category: ErrorUnknown}
+	}
+
+	r := &errReader{r: io.LimitReader(resp.Body, diagMaxDownload+1)}
+	switch {
+	case s.edDSAPublicKey != "":
+		err = sign.VerifyEdDSA(s.edDSAPublicKey, r, enc.EdSignature)
+	case s.dsaPublicKey != "":
+		err = sign.VerifyDSA(s.dsaPublicKey, r, enc.DSASignature) // Hashed while reading.
+	default:
+		return &UpdateError{Category: ErrorUnknown}
+	}
+	switch {
+	case r.err != nil:
+		return requestError(ctx, ErrorDownload, r.err)
+	case r.n > diagMaxDownload:
+		return &UpdateError{Category: ErrorUnknown}
+	case err != nil:
+		return &UpdateError{Category: ErrorSignature, Err: err}
+	default:
+		return &UpdateError{Category: ErrorUnknown}
+	}
+}
+
+// feedClient returns a client making WinSparkle's requests with client.
+func (s *diagState) feedClient(client *http.Client) *feed.Client {
+	return &feed.Client{HTTP: client, UserAgent: feed.UserAgent(s.app, s.version), Headers: s.headers}
+}
+
+// requestError returns an error of category c, or of [ErrorUnknown] if the
+// request failed because the diagnosis ran out of time or was cancelled.
+func requestError(ctx context.Context, c ErrorCategory, err error) *UpdateError {
+	if ctx.Err() != nil {
+		c = ErrorUnknown
+	}
+	return &UpdateError{Category: c, Err: err}
+}
+
+// errReader records the error returned by the underlying reader, telling
+// apart failed downloads from invalid signatures, and the number of bytes
+// read.
+type errReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.err = err
+	}
+	return n, err
+}
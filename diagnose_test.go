@@ -0,0 +1,218 @@
+package winsparkle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abemedia/go-winsparkle/appcast"
+	"github.com/abemedia/go-winsparkle/sign"
+)
+
+const testPayload = "installer"
+
+func TestDiagnose(t *testing.T) {
+	pub, priv, err := sign.GenerateEdDSAKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _, err := sign.SignEdDSA(priv, strings.NewReader(testPayload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := "http://" + r.Host
+		switch r.URL.Path {
+		case "/install.msi":
+			w.Write([]byte(testPayload))
+		case "/appcast.xml", "/unsigned.xml", "/missing.xml", "/length.xml":
+			if r.Header.Get("X-Test") != "1" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			enc := appcast.Enclosure{URL: host + "/install.msi", Version: "2.0", EdSignature: sig}
+			switch r.URL.Path {
+			case "/unsigned.xml":
+				enc.EdSignature = ""
+			case "/missing.xml":
+				enc.URL = host + "/missing.msi"
+			case "/length.xml":
+				enc.Length = int64(len(testPayload)) + 1
+			}
+			a := &appcast.Appcast{Channel: appcast.Channel{Items: []appcast.Item{{
+				Enclosures: []appcast.Enclosure{
+					{URL: host + "/arm64.msi", Version: "3.0", OS: "windows-arm64"},
+					enc,
+				},
+			}}}}
+			a.Encode(w)
+		case "/invalid.xml":
+			w.Write([]byte("<rss>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name     string
+		state    diagState
+		category ErrorCategory
+		code     int
+	}{
+		{name: "no url", category: ErrorUnknown},
+		{name: "network", state: diagState{appcastURL: closed.URL}, category: ErrorNetwork},
+		{name: "http status", state: diagState{appcastURL: s.URL + "/appcast.xml"}, category: ErrorHTTPStatus, code: http.StatusForbidden},
+		{name: "appcast", state: diagState{appcastURL: s.URL + "/invalid.xml"}, category: ErrorAppcast},
+		{name: "no update", state: diagState{appcastURL: s.URL + "/appcast.xml"}, category: ErrorUnknown},
+		{name: "download", state: diagState{appcastURL: s.URL + "/missing.xml", foundUpdate: true}, category: ErrorDownload, code: http.StatusNotFound},
+		{name: "not signed", state: diagState{appcastURL: s.URL + "/unsigned.xml", foundUpdate: true, edDSAPublicKey: pub}, category: ErrorSignature},
+		{name: "wrong key", state: diagState{appcastURL: s.URL + "/appcast.xml", foundUpdate: true, edDSAPublicKey: strings.Repeat("A", 43) + "="}, category: ErrorSignature},
+		{name: "length", state: diagState{appcastURL: s.URL + "/length.xml", foundUpdate: true, edDSAPublicKey: pub}, category: ErrorSignature},
+		{name: "verified", state: diagState{appcastURL: s.URL + "/appcast.xml", foundUpdate: true, edDSAPublicKey: pub}, category: ErrorUnknown},
+		{name: "run installer", state: diagState{installerErr: errors.New("failed")}, category: ErrorInstaller},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := test.state
+			state.os = "windows-x64"
+			if test.name != "http status" {
+				state.headers = [][2]string{{"X-Test", "1"}}
+			}
+
+			err := state.diagnose(context.Background(), s.Client())
+			if err.Category != test.category || err.StatusCode != test.code {
+				t.Errorf("unexpected error: %v, status %d", err, err.StatusCode)
+			}
+		})
+	}
+}
+
+func TestDiagnoseMaxDownload(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush() // Omit Content-Length.
+		w.Write([]byte(testPayload))
+	}))
+	defer s.Close()
+
+	defer func(n int64) { diagMaxDownload = n }(diagMaxDownload)
+	diagMaxDownload = int64(len(testPayload)) - 1
+
+	state := diagState{edDSAPublicKey: strings.Repeat("A", 43) + "="}
+	enc := &appcast.Enclosure{URL: s.URL, EdSignature: strings.Repeat("A", 86) + "=="}
+	if err := state.verify(context.Background(), s.Client(), enc); err.Category != ErrorUnknown {
+		t.Errorf("should not verify large update: %v", err)
+	}
+}
+
+func TestDiagnosePrefetched(t *testing.T) {
+	f := &appcastFetch{done: make(chan struct{}), err: &UpdateError{Category: ErrorAppcast}}
+	state := diagState{appcastURL: "http://127.0.0.1:0/appcast.xml", appcast: f}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := state.diagnose(ctx, http.DefaultClient); err.Category != ErrorUnknown {
+		t.Errorf("should give up waiting for appcast: %v", err)
+	}
+
+	close(f.done)
+	if err := state.diagnose(context.Background(), http.DefaultClient); err != f.err {
+		t.Errorf("should use fetched appcast: %v", err)
+	}
+}
+
+func TestDiagnoseTimeout(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	state := diagState{appcastURL: s.URL}
+	if err := state.diagnose(ctx, s.Client()); err.Category != ErrorUnknown {
+		t.Errorf("timeout should be unknown: %v", err)
+	}
+}
+
+func TestDispatchError(t *testing.T) {
+	diag.set(func(s *diagState) { s.installerErr = errors.New("failed") })
+
+	ch := make(chan error, 1)
+	SetErrorHandler(func(err error) { ch <- err })
+	defer SetErrorHandler(nil)
+
+	events, unsubscribe := Subscribe(1)
+	defer unsubscribe()
+
+	h := eventHooks[EventError]
+	var called bool
+	h.mu.Lock()
+	id := h.addLocked(func() { called = true })
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		h.removeLocked(id)
+		h.mu.Unlock()
+	}()
+
+	dispatchEvent(h, EventError)
+	if !called {
+		t.Error("should call listener right away")
+	}
+	if s := diag.observe(EventDidNotFindUpdate); s.installerErr != nil {
+		t.Error("should reset state")
+	}
+
+	var got error
+	select {
+	case got = <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("should call handler")
+	}
+	var updateErr *UpdateError
+	if !errors.As(got, &updateErr) || updateErr.Category != ErrorInstaller {
+		t.Errorf("unexpected error: %v", got)
+	}
+	if e := <-events; e.Err != got {
+		t.Errorf("unexpected event error: %v", e.Err)
+	}
+}
+
+func TestDiagnosticsStart(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		(&appcast.Appcast{}).Encode(w)
+	}))
+	defer s.Close()
+
+	diag.set(func(st *diagState) { st.appcastURL = s.URL })
+	defer diag.set(func(st *diagState) { st.appcastURL = "" })
+	defer diag.stop()
+
+	diag.start()
+	if diag.observe(EventDidFindUpdate).appcast != nil {
+		t.Error("should not fetch appcast without error handler")
+	}
+
+	SetErrorHandler(func(error) {})
+	defer SetErrorHandler(nil)
+	diag.start()
+
+	f := diag.observe(EventDidFindUpdate).appcast
+	if f == nil {
+		t.Fatal("should fetch appcast")
+	}
+	<-f.done
+	if f.err != nil || f.a == nil {
+		t.Errorf("unexpected result: %v", f.err)
+	}
+}
@@ -1,6 +1,5 @@
 //go:build windows
 
-// Package dll loads the WinSparkle DLL.
 package dll
 
 import (
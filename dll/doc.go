@@ -0,0 +1,5 @@
+// Package dll loads the WinSparkle DLL.
+//
+// Importing this package embeds WinSparkle.dll into the executable and
+// extracts it on startup. On platforms other than Windows it does nothing.
+package dll
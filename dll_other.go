@@ -0,0 +1,23 @@
+//go:build !windows
+
+package winsparkle
+
+// WinSparkle is only available on Windows. On other platforms every procedure
+// call is a no-op returning ErrUnsupported.
+var winsparkle lazyDLL
+
+type lazyDLL struct{}
+
+func (lazyDLL) Load() error { return ErrUnsupported }
+
+func (lazyDLL) NewProc(string) lazyProc { return lazyProc{} }
+
+type lazyProc struct{}
+
+func (lazyProc) Find() error { return ErrUnsupported }
+
+func (lazyProc) Call(...uintptr) (r1, r2 uintptr, err error) {
+	return 0, 0, ErrUnsupported
+}
+
+func newCallback(any) uintptr { return 0 }
@@ -0,0 +1,13 @@
+//go:build windows
+
+package winsparkle
+
+import "syscall"
+
+var winsparkle = syscall.NewLazyDLL(dllName)
+
+type lazyProc = *syscall.LazyProc
+
+func newCallback(fn any) uintptr {
+	return syscall.NewCallbackCDecl(fn)
+}
@@ -0,0 +1,134 @@
+package winsparkle
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	// ErrUnsupported is returned on platforms other than Windows.
+	ErrUnsupported = errors.New("winsparkle: unsupported platform")
+
+	// ErrInvalidPublicKey is returned if WinSparkle rejects a public key.
+	ErrInvalidPublicKey = errors.New("winsparkle: invalid public key")
+
+	// ErrInvalidValue is reported if a string passed to WinSparkle, e.g. a
+	// config value, contains a NUL character.
+	ErrInvalidValue = errors.New("winsparkle: value contains NUL character")
+
+	// ErrValueTooLong is reported if a config value doesn't fit into the
+	// buffer provided by WinSparkle.
+	ErrValueTooLong = errors.New("winsparkle: config value too long")
+)
+
+// CallError is returned if a WinSparkle function can't be called because
+// WinSparkle.dll or the function failed to load.
+type CallError struct {
+	// Func is the name of the WinSparkle function, e.g. "win_sparkle_init",
+	// or "WinSparkle.dll" if returned by [Load].
+	Func string
+
+	// Err is the error returned by the loader.
+	Err error
+}
+
+func (e *CallError) Error() string {
+	return "winsparkle: failed to load " + e.Func + ": " + e.Err.Error()
+}
+
+func (e *CallError) Unwrap() error {
+	return e.Err
+}
+
+// ArgError is returned if a string argument can't be passed to WinSparkle
+// because it contains a NUL character. It wraps [ErrInvalidValue].
+type ArgError struct {
+	// Arg is the name of the argument, e.g. "url".
+	Arg string
+}
+
+func (e *ArgError) Error() string {
+	return "winsparkle: invalid " + e.Arg + ": contains NUL character"
+}
+
+func (e *ArgError) Unwrap() error {
+	return ErrInvalidValue
+}
+
+// ErrorCategory classifies the cause of an [UpdateError].
+type ErrorCategory int
+
+// The categories of update errors.
+const (
+	// ErrorUnknown means the cause of the error couldn't be determined.
+	ErrorUnknown ErrorCategory = iota
+
+	// ErrorNetwork means the appcast couldn't be fetched, e.g. because the
+	// server is unreachable or the connection failed.
+	ErrorNetwork
+
+	// ErrorHTTPStatus means the server responded to the appcast request with
+	// a status other than 200 OK.
+	ErrorHTTPStatus
+
+	// ErrorAppcast means the appcast couldn't be parsed.
+	ErrorAppcast
+
+	// ErrorDownload means the update couldn't be downloaded.
+	ErrorDownload
+
+	// ErrorSignature means the update's signature doesn't match the public
+	// key or is missing.
+	ErrorSignature
+
+	// ErrorInstaller means the downloaded update couldn't be launched.
+	ErrorInstaller
+)
+
+var errorCategoryNames = map[ErrorCategory]string{
+	ErrorUnknown:    "unknown error",
+	ErrorNetwork:    "network error",
+	ErrorHTTPStatus: "unexpected HTTP status",
+	ErrorAppcast:    "invalid appcast",
+	ErrorDownload:   "download failed",
+	ErrorSignature:  "signature mismatch",
+	ErrorInstaller:  "installer failed",
+}
+
+func (c ErrorCategory) String() string {
+	if s, ok := errorCategoryNames[c]; ok {
+		return s
+	}
+	return "ErrorCategory(" + strconv.Itoa(int(c)) + ")"
+}
+
+// UpdateError describes an error reported by WinSparkle's error callback. It
+// matches [ErrCheckFailed] when used with [errors.Is].
+type UpdateError struct {
+	// Category is the cause of the error.
+	Category ErrorCategory
+
+	// StatusCode is the HTTP status code for [ErrorHTTPStatus], and for
+	// [ErrorDownload] if the server responded with an unexpected status.
+	StatusCode int
+
+	// Err is the underlying error, if known.
+	Err error
+}
+
+func (e *UpdateError) Error() string {
+	s := "winsparkle: update failed: " + e.Category.String()
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+func (e *UpdateError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is [ErrCheckFailed].
+func (e *UpdateError) Is(target error) bool {
+	return target == ErrCheckFailed
+}
@@ -0,0 +1,57 @@
+package winsparkle_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abemedia/go-winsparkle"
+)
+
+func TestArgError(t *testing.T) {
+	tests := []struct {
+		arg string
+		fn  func() error
+	}{
+		{"url", func() error { return winsparkle.SetAppcastURL("https://example.com/\x00") }},
+		{"version", func() error { return winsparkle.SetAppDetails("Test", "Test", "1.0\x00") }},
+		{"value", func() error { return winsparkle.SetHTTPHeader("X-Test", "\x00") }},
+		{"key", func() error { return winsparkle.SetEdDSAPublicKey("\x00") }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.arg, func(t *testing.T) {
+			err := test.fn()
+			var argErr *winsparkle.ArgError
+			if !errors.As(err, &argErr) || argErr.Arg != test.arg {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !errors.Is(err, winsparkle.ErrInvalidValue) {
+				t.Error("should wrap ErrInvalidValue")
+			}
+		})
+	}
+}
+
+func TestCallError(t *testing.T) {
+	err := &winsparkle.CallError{Func: "win_sparkle_init", Err: errors.New("not found")}
+	if want := "winsparkle: failed to load win_sparkle_init: not found"; err.Error() != want {
+		t.Errorf("got %q want %q", err.Error(), want)
+	}
+	if !errors.Is(err, err.Err) {
+		t.Error("should unwrap to loader error")
+	}
+}
+
+func TestUpdateError(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := &winsparkle.UpdateError{Category: winsparkle.ErrorNetwork, Err: cause}
+	if want := "winsparkle: update failed: network error: connection refused"; err.Error() != want {
+		t.Errorf("got %q want %q", err.Error(), want)
+	}
+	if !errors.Is(err, cause) || !errors.Is(err, winsparkle.ErrCheckFailed) {
+		t.Error("should match cause and ErrCheckFailed")
+	}
+	if s := winsparkle.ErrorCategory(-1).String(); s != "ErrorCategory(-1)" {
+		t.Errorf("unexpected string: %q", s)
+	}
+}
@@ -0,0 +1,180 @@
+package winsparkle
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/abemedia/go-winsparkle/internal/broadcast"
+)
+
+// EventKind identifies the WinSparkle callback which caused an [Event].
+type EventKind int
+
+// The kinds of events delivered to subscribers.
+const (
+	// EventDidFindUpdate is sent when the updater did find an update.
+	EventDidFindUpdate EventKind = iota + 1
+
+	// EventDidNotFindUpdate is sent when the updater did not find an update.
+	EventDidNotFindUpdate
+
+	// EventError is sent when the updater encounters an error.
+	EventError
+
+	// EventUpdateCancelled is sent when the user cancels a download.
+	EventUpdateCancelled
+
+	// EventUpdateSkipped is sent when the user skips an update.
+	EventUpdateSkipped
+
+	// EventUpdatePostponed is sent when the user postpones an update.
+	EventUpdatePostponed
+
+	// EventUpdateDismissed is sent when the user dismisses the update dialog.
+	EventUpdateDismissed
+
+	// EventShutdownRequested is sent when WinSparkle launched the installer
+	// and asks the application to shut down.
+	EventShutdownRequested
+)
+
+var eventNames = map[EventKind]string{
+	EventDidFindUpdate:     "did find update",
+	EventDidNotFindUpdate:  "did not find update",
+	EventError:             "error",
+	EventUpdateCancelled:   "update cancelled",
+	EventUpdateSkipped:     "update skipped",
+	EventUpdatePostponed:   "update postponed",
+	EventUpdateDismissed:   "update dismissed",
+	EventShutdownRequested: "shutdown requested",
+}
+
+func (k EventKind) String() string {
+	if s, ok := eventNames[k]; ok {
+		return s
+	}
+	return "EventKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Event is sent to subscribers when WinSparkle calls one of its callbacks.
+type Event struct {
+	Kind EventKind
+
+	// Err is an [*UpdateError] describing the cause of an [EventError].
+	Err error
+}
+
+// eventProcs contains the functions setting the callback for each kind of
+// event.
+var eventProcs = map[EventKind]*proc{
+	EventDidFindUpdate:     procSetDidFindUpdateCallback,
+	EventDidNotFindUpdate:  procSetDidNotFindUpdateCallback,
+	EventError:             procSetErrorCallback,
+	EventUpdateCancelled:   procSetUpdateCancelledCallback,
+	EventUpdateSkipped:     procSetUpdateSkippedCallback,
+	EventUpdatePostponed:   procSetUpdatePostponedCallback,
+	EventUpdateDismissed:   procSetUpdateDismissedCallback,
+	EventShutdownRequested: procSetShutdownRequestCallback,
+}
+
+var (
+	eventHub   broadcast.Hub[Event]
+	eventHooks = newEventHooks()
+)
+
+func newEventHooks() map[EventKind]*hook[func()] {
+	hooks := make(map[EventKind]*hook[func()], len(eventProcs))
+	for kind, p := range eventProcs {
+		kind := kind
+		hooks[kind] = newHook(p, func(h *hook[func()]) uintptr {
+			return newCallback(func() uintptr { dispatchEvent(h, kind); return 0 })
+		})
+	}
+	return hooks
+}
+
+// Subscribe returns a channel receiving an [Event] whenever WinSparkle calls
+// one of its callbacks, in addition to the functions passed to the
+// Set*Callback functions. There may be any number of subscribers.
+//
+// Events are sent without blocking WinSparkle, i.e. they are dropped if the
+// channel's buffer is full. A buffer smaller than 1 is raised to 1. Call
+// unsubscribe to stop receiving events and close the channel. WinSparkle's
+// callbacks are reset once the last subscriber and all other handlers are
+// gone.
+//
+// Without a shutdown request callback or listener WinSparkle closes the
+// application's windows itself, so [EventShutdownRequested] is only sent if
+// one of them is set.
+func Subscribe(buffer int) (events <-chan Event, unsubscribe func()) {
+	for kind, h := range eventHooks {
+		if kind != EventShutdownRequested {
+			_ = h.register() // Ignore callbacks missing from older DLLs.
+		}
+	}
+	events, unsubscribeHub := eventHub.Subscribe(buffer)
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			unsubscribeHub()
+			for kind, h := range eventHooks {
+				if kind != EventShutdownRequested {
+					_ = h.unregister()
+				}
+			}
+		})
+	}
+}
+
+// AddListener adds a function called when WinSparkle sends an event of the
+// given kind, in addition to the function passed to the Set*Callback
+// function. Listeners are called in the order they were added.
+//
+// Call remove to remove the listener.
+func AddListener(kind EventKind, fn func()) (remove func(), err error) {
+	h, ok := eventHooks[kind]
+	if !ok {
+		return nil, errors.New("winsparkle: invalid event kind " + kind.String())
+	}
+	return h.add(fn)
+}
+
+// setEventCallback sets the function passed to the Set*Callback function for
+// kind. A nil function unsets it.
+func setEventCallback(kind EventKind, cb func()) error {
+	return eventHooks[kind].set(cb, cb != nil)
+}
+
+// dispatchEvent sends the event to the subscribers and calls the handlers of
+// its hook. A panicking handler doesn't stop the others from being called.
+//
+// If there is an error handler or subscriber the cause of an [EventError] is
+// diagnosed. This may involve network requests, so it happens in the
+// background to not block WinSparkle, and the event is sent to subscribers
+// and the error handler afterwards. The handlers of the hook are still called
+// right away.
+func dispatchEvent(h *hook[func()], kind EventKind) {
+	s := diag.observe(kind)
+	handler := diag.errorHandler()
+	if kind != EventError || (handler == nil && eventHub.Len() == 0) {
+		eventHub.Publish(Event{Kind: kind})
+		callHandlers(h)
+		return
+	}
+	callHandlers(h)
+	go func() {
+		err := diag.diagnose(&s)
+		eventHub.Publish(Event{Kind: kind, Err: err})
+		if handler != nil {
+			protect(func() { handler(err) })
+		}
+	}()
+}
+
+func callHandlers(h *hook[func()]) {
+	for _, fn := range h.handlers() {
+		protect(fn)
+	}
+}
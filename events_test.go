@@ -0,0 +1,60 @@
+package winsparkle
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDispatchEvent(t *testing.T) {
+	var called []EventKind
+	SetDidFindUpdateCallback(func() { called = append(called, EventDidFindUpdate) })
+	defer SetDidFindUpdateCallback(nil)
+
+	a, unsubscribeA := Subscribe(1)
+	defer unsubscribeA()
+	b, unsubscribeB := Subscribe(1)
+	defer unsubscribeB()
+
+	dispatchEvent(eventHooks[EventDidFindUpdate], EventDidFindUpdate)
+	dispatchEvent(eventHooks[EventDidNotFindUpdate], EventDidNotFindUpdate) // No callback set.
+
+	if len(called) != 1 {
+		t.Errorf("unexpected callbacks: %v", called)
+	}
+	for _, ch := range []<-chan Event{a, b} {
+		if e := <-ch; e.Kind != EventDidFindUpdate {
+			t.Errorf("unexpected event: %v", e.Kind)
+		}
+	}
+}
+
+func TestSubscribeUnregister(t *testing.T) {
+	keep := func() map[EventKind]int {
+		m := map[EventKind]int{}
+		for kind, h := range eventHooks {
+			m[kind] = h.keep
+		}
+		return m
+	}
+	before := keep()
+
+	_, unsubscribe := Subscribe(1)
+	during := keep()
+	if during[EventError] != before[EventError]+1 || during[EventShutdownRequested] != before[EventShutdownRequested] {
+		t.Error("should register callbacks except shutdown request")
+	}
+	unsubscribe()
+	unsubscribe() // Safe to call twice.
+	if after := keep(); !reflect.DeepEqual(after, before) {
+		t.Errorf("should unregister callbacks: %v", after)
+	}
+}
+
+func TestEventKindString(t *testing.T) {
+	if s := EventShutdownRequested.String(); s != "shutdown requested" {
+		t.Errorf("unexpected string: %q", s)
+	}
+	if s := EventKind(0).String(); s != "EventKind(0)" {
+		t.Errorf("unexpected string: %q", s)
+	}
+}
@@ -3,6 +3,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"time"
 
@@ -13,34 +14,39 @@ import (
 func main() {
 	log.Println("starting app")
 
-	winsparkle.SetAppcastURL("https://winsparkle.org/example/appcast.xml")
-	winsparkle.SetAppDetails("winsparkle.org", "WinSparkle Go Example", "1.0.0")
+	if err := winsparkle.SetAppcastURL("https://winsparkle.org/example/appcast.xml"); err != nil {
+		log.Fatal(err)
+	}
+	if err := winsparkle.SetAppDetails("winsparkle.org", "WinSparkle Go Example", "1.0.0"); err != nil {
+		log.Fatal(err)
+	}
 
 	if err := winsparkle.SetEdDSAPublicKey("payYa5ap0XtF8HWR4AYBdCIcXWtJZPen7bJqFcqlp7o="); err != nil {
 		log.Fatal(err)
 	}
 
-	c := make(chan struct{})
-
 	winsparkle.SetShutdownRequestCallback(func() {
 		log.Println("installing update")
-		close(c)
 	})
 
 	winsparkle.SetUpdateCancelledCallback(func() {
 		log.Println("cancelled update")
-		close(c)
 	})
 
-	winsparkle.Init()
+	if err := winsparkle.Init(); err != nil {
+		log.Fatal(err)
+	}
 	defer winsparkle.Cleanup()
 
-	winsparkle.CheckUpdateWithUI()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
 
-	// waits until update is installed or cancelled (10min timeout)
-	select {
-	case <-c:
-	case <-time.After(10 * time.Minute):
+	// waits until the update is installed, skipped, postponed, cancelled or
+	// dismissed (10min timeout)
+	res, err := winsparkle.CheckForUpdateWithUI(ctx)
+	if err != nil {
+		log.Fatal(err)
 	}
+	log.Println(res)
 	log.Println("shutting down")
 }
@@ -1,26 +1,32 @@
-//go:build windows
-
 package winsparkle
 
 import (
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"unicode/utf16"
 	"unsafe"
 )
 
-func char(s string) uintptr {
-	b, err := syscall.BytePtrFromString(s)
+// char returns s as a NUL-terminated UTF-8 string or an [*ArgError] for the
+// argument arg if s contains a NUL character.
+func char(arg, s string) (*byte, error) {
+	p, err := syscall.BytePtrFromString(s)
 	if err != nil {
-		panic(err)
+		return nil, &ArgError{Arg: arg}
 	}
-	return uintptr(unsafe.Pointer(b))
+	return p, nil
 }
 
-func wchar(s string) uintptr {
-	i, err := syscall.UTF16PtrFromString(s)
-	if err != nil {
-		panic(err)
+// wchar returns s as a NUL-terminated UTF-16 string or an [*ArgError] for the
+// argument arg if s contains a NUL character.
+func wchar(arg, s string) (*uint16, error) {
+	if strings.IndexByte(s, 0) != -1 {
+		return nil, &ArgError{Arg: arg}
 	}
-	return uintptr(unsafe.Pointer(i))
+	u := append(utf16.Encode([]rune(s)), 0)
+	return &u[0], nil
 }
 
 func boolean(b bool) uintptr {
@@ -53,28 +59,103 @@ func utf16PtrToString(p *uint16) string {
 	for ptr := unsafe.Pointer(p); *(*uint16)(ptr) != 0; n++ {
 		ptr = unsafe.Add(ptr, unsafe.Sizeof(*p))
 	}
-	return syscall.UTF16ToString(unsafe.Slice(p, n))
+	return string(utf16.Decode(unsafe.Slice(p, n)))
 }
 
-func configMethods(cs ConfigStore) unsafe.Pointer {
+var (
+	configStore   atomic.Pointer[ConfigStore]
+	configOnce    sync.Once
+	configMethods struct{ read, write, delete, _ uintptr }
+)
+
+// setConfigStore makes the callbacks in configMethods call the methods of cs
+// and returns a pointer to them as a win_sparkle_config_methods_t, or nil if
+// cs is nil.
+//
+// The callbacks are only created once, as Go limits the number of callbacks
+// per process.
+func setConfigStore(cs ConfigStore) unsafe.Pointer {
 	if cs == nil {
+		configStore.Store(nil)
 		return nil
 	}
-	return unsafe.Pointer(&struct{ read, write, delete, _ uintptr }{
-		read: syscall.NewCallbackCDecl(func(name *uint8, buf *uint16, size uint, _ uintptr) uintptr {
-			s, ok := cs.Read(utf8PtrToString(name))
-			if !ok {
-				return 0
+	configStore.Store(&cs)
+	configOnce.Do(func() {
+		configMethods.read = newCallback(func(name *uint8, buf *uint16, size uintptr, _ uintptr) uintptr {
+			if store := configStore.Load(); store != nil {
+				return configRead(*store, name, buf, size)
+			}
+			return 0
+		})
+		configMethods.write = newCallback(func(name *uint8, value *uint16, _ uintptr) uintptr {
+			if store := configStore.Load(); store != nil {
+				return configWrite(*store, name, value)
 			}
-			b := (*[1 << 29]uint8)(unsafe.Pointer(buf))[:size:size]
-			copy(b, s)
-			return 1
-		}),
-		write: syscall.NewCallbackCDecl(func(name *uint8, value *uint16, _ uintptr) uintptr {
-			return boolean(cs.Write(utf8PtrToString(name), utf16PtrToString(value)))
-		}),
-		delete: syscall.NewCallbackCDecl(func(name *uint8, _ uintptr) uintptr {
-			return boolean(cs.Delete(utf8PtrToString(name)))
-		}),
+			return 0
+		})
+		configMethods.delete = newCallback(func(name *uint8, _ uintptr) uintptr {
+			if store := configStore.Load(); store != nil {
+				return configDelete(*store, name)
+			}
+			return 0
+		})
 	})
+	return unsafe.Pointer(&configMethods)
+}
+
+// configRead implements the read callback of win_sparkle_config_methods_t. It
+// writes the value to buf, which has room for size UTF-16 code units
+// including the NUL terminator, and returns 1 if it was found.
+//
+// Values which don't fit into buf or contain a NUL character are treated as
+// missing rather than truncated and reported to cs if it implements
+// [ConfigErrorHandler].
+func configRead(cs ConfigStore, name *uint8, buf *uint16, size uintptr) uintptr {
+	key := utf8PtrToString(name)
+	var (
+		s  string
+		ok bool
+	)
+	if !protect(func() { s, ok = cs.Read(key) }) || !ok {
+		return 0
+	}
+	if buf == nil || size == 0 {
+		return 0
+	}
+	if err := putUTF16(unsafe.Slice(buf, size), s); err != nil {
+		if h, isHandler := cs.(ConfigErrorHandler); isHandler {
+			protect(func() { h.ConfigError(key, err) })
+		}
+		return 0
+	}
+	return 1
+}
+
+// configWrite implements the write callback of win_sparkle_config_methods_t.
+func configWrite(cs ConfigStore, name *uint8, value *uint16) uintptr {
+	var ok bool
+	protect(func() { ok = cs.Write(utf8PtrToString(name), utf16PtrToString(value)) })
+	return boolean(ok)
+}
+
+// configDelete implements the delete callback of
+// win_sparkle_config_methods_t.
+func configDelete(cs ConfigStore, name *uint8) uintptr {
+	var ok bool
+	protect(func() { ok = cs.Delete(utf8PtrToString(name)) })
+	return boolean(ok)
+}
+
+// putUTF16 writes s to buf as a NUL-terminated UTF-16 string.
+func putUTF16(buf []uint16, s string) error {
+	if strings.IndexByte(s, 0) != -1 {
+		return ErrInvalidValue
+	}
+	u := utf16.Encode([]rune(s))
+	if len(u) >= len(buf) {
+		return ErrValueTooLong
+	}
+	copy(buf, u)
+	buf[len(u)] = 0
+	return nil
 }
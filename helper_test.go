@@ -0,0 +1,127 @@
+package winsparkle
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"testing"
+	"unicode/utf16"
+)
+
+type testStore struct {
+	values map[string]string
+	errs   map[string]error
+}
+
+func (s *testStore) Read(name string) (string, bool) {
+	v, ok := s.values[name]
+	return v, ok
+}
+
+func (s *testStore) Write(name, value string) bool {
+	s.values[name] = value
+	return true
+}
+
+func (s *testStore) Delete(name string) bool {
+	delete(s.values, name)
+	return true
+}
+
+func (s *testStore) ConfigError(name string, err error) {
+	s.errs[name] = err
+}
+
+func TestConfigRead(t *testing.T) {
+	store := &testStore{
+		values: map[string]string{
+			"Ascii":     "1.0",
+			"Unicode":   "Grüße🚀",
+			"Exact":     strings.Repeat("a", 7),
+			"Long":      strings.Repeat("a", 8),
+			"Surrogate": strings.Repeat("a", 6) + "🚀",
+			"NUL":       "a\x00b",
+			"Empty":     "",
+		},
+		errs: map[string]error{},
+	}
+
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+		err  error
+	}{
+		{name: "Ascii", want: "1.0", ok: true},
+		{name: "Unicode", want: "Grüße🚀", ok: true},
+		{name: "Exact", want: strings.Repeat("a", 7), ok: true},
+		{name: "Empty", want: "", ok: true},
+		{name: "Missing"},
+		{name: "Long", err: ErrValueTooLong},
+		{name: "Surrogate", err: ErrValueTooLong},
+		{name: "NUL", err: ErrInvalidValue},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := make([]uint16, 10)
+			for i := range buf {
+				buf[i] = 0xffff
+			}
+			name, err := syscall.BytePtrFromString(test.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ok := configRead(store, name, &buf[0], 8) == 1
+			if ok != test.ok {
+				t.Fatalf("got ok %t want %t", ok, test.ok)
+			}
+			if !errors.Is(store.errs[test.name], test.err) {
+				t.Errorf("got error %v want %v", store.errs[test.name], test.err)
+			}
+			if buf[8] != 0xffff || buf[9] != 0xffff {
+				t.Error("wrote past end of buffer")
+			}
+			if !ok {
+				return
+			}
+			if got := utf16PtrToString(&buf[0]); got != test.want {
+				t.Errorf("got %q want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestConfigReadEmptyBuffer(t *testing.T) {
+	store := &testStore{values: map[string]string{"a": "b"}}
+	name, _ := syscall.BytePtrFromString("a")
+	if configRead(store, name, nil, 0) != 0 {
+		t.Error("should not read into empty buffer")
+	}
+}
+
+func TestUTF16PtrToString(t *testing.T) {
+	for _, s := range []string{"", "1.0", "Grüße🚀"} {
+		u := append(utf16.Encode([]rune(s)), 0)
+		if got := utf16PtrToString(&u[0]); got != s {
+			t.Errorf("got %q want %q", got, s)
+		}
+	}
+}
+
+func TestSetConfigStore(t *testing.T) {
+	defer setConfigStore(nil)
+
+	a := setConfigStore(&testStore{values: map[string]string{"a": "1"}})
+	b := setConfigStore(&testStore{values: map[string]string{"a": "2"}})
+	if a == nil || a != b {
+		t.Error("should reuse callbacks")
+	}
+	if v, _ := (*configStore.Load()).Read("a"); v != "2" {
+		t.Errorf("should use latest store: %q", v)
+	}
+	if setConfigStore(nil) != nil || configStore.Load() != nil {
+		t.Error("should reset store")
+	}
+}
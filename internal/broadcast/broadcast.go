@@ -0,0 +1,61 @@
+// Package broadcast delivers values to any number of subscribers.
+package broadcast
+
+import "sync"
+
+// Hub delivers published values to its subscribers. The zero value is ready
+// to use.
+type Hub[T any] struct {
+	mu     sync.Mutex
+	subs   map[int]chan T
+	nextID int
+}
+
+// Subscribe returns a channel receiving published values and a function
+// closing it. Values are dropped if the channel's buffer is full, so
+// publishers never block. A buffer smaller than 1 is raised to 1, as an
+// unbuffered channel would only receive values while its reader is waiting.
+func (h *Hub[T]) Subscribe(buffer int) (<-chan T, func()) {
+	if buffer < 1 {
+		buffer = 1
+	}
+	ch := make(chan T, buffer)
+
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = map[int]chan T{}
+	}
+	id := h.nextID
+	h.nextID++
+	h.subs[id] = ch
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, id)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends v to all subscribers.
+func (h *Hub[T]) Publish(v T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ch := range h.subs {
+		select {
+		case ch <- v:
+		default:
+		}
+	}
+}
+
+// Len returns the number of subscribers.
+func (h *Hub[T]) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
@@ -0,0 +1,57 @@
+package broadcast_test
+
+import (
+	"testing"
+
+	"github.com/abemedia/go-winsparkle/internal/broadcast"
+)
+
+func TestHub(t *testing.T) {
+	var h broadcast.Hub[int]
+
+	a, unsubscribeA := h.Subscribe(2)
+	b, unsubscribeB := h.Subscribe(1)
+	defer unsubscribeB()
+
+	if n := h.Len(); n != 2 {
+		t.Errorf("unexpected number of subscribers: %d", n)
+	}
+
+	h.Publish(1)
+	h.Publish(2) // Dropped for b.
+
+	unsubscribeA()
+	unsubscribeA() // Safe to call twice.
+	h.Publish(3)
+
+	if n := h.Len(); n != 1 {
+		t.Errorf("unexpected number of subscribers: %d", n)
+	}
+
+	var got []int
+	for v := range a {
+		got = append(got, v)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("unexpected values: %v", got)
+	}
+	if v := <-b; v != 1 {
+		t.Errorf("unexpected value: %d", v)
+	}
+	select {
+	case v := <-b:
+		t.Errorf("unexpected value: %d", v)
+	default:
+	}
+}
+
+func TestHubUnbuffered(t *testing.T) {
+	var h broadcast.Hub[int]
+	ch, unsubscribe := h.Subscribe(0)
+	defer unsubscribe()
+
+	h.Publish(1)
+	if v := <-ch; v != 1 {
+		t.Errorf("unexpected value: %d", v)
+	}
+}
@@ -0,0 +1,126 @@
+// Package feed requests appcasts and updates the way WinSparkle does.
+package feed
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/abemedia/go-winsparkle/appcast"
+)
+
+// Client makes WinSparkle's requests.
+type Client struct {
+	// HTTP is the client used for requests. If nil, [http.DefaultClient] is
+	// used.
+	HTTP *http.Client
+
+	// UserAgent is sent with every request, see [UserAgent].
+	UserAgent string
+
+	// Headers are sent with requests for the appcast.
+	Headers [][2]string
+}
+
+// UserAgent returns the User-Agent header WinSparkle sends for the
+// application's name and version.
+func UserAgent(app, version string) string {
+	if app == "" {
+		return "WinSparkle"
+	}
+	return app + "/" + version + " WinSparkle"
+}
+
+// StatusError is returned for responses other than 200 OK.
+type StatusError struct {
+	URL        string
+	Status     string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return "GET " + e.URL + ": unexpected status " + e.Status
+}
+
+// ParseError is returned for appcasts which can't be parsed.
+type ParseError struct {
+	Err error
+}
+
+func (e *ParseError) Error() string { return e.Err.Error() }
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// Get requests url, adding the headers if headers is true. It returns a
+// [*StatusError] for responses other than 200 OK.
+func (c *Client) Get(ctx context.Context, url string, headers bool) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	if headers {
+		for _, h := range c.Headers {
+			req.Header.Add(h[0], h[1])
+		}
+	}
+
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, &StatusError{URL: resp.Request.URL.String(), Status: resp.Status, StatusCode: resp.StatusCode}
+	}
+	return resp, nil
+}
+
+// Appcast fetches and parses the appcast at url. It returns a [*StatusError]
+// for unexpected responses and a [*ParseError] for invalid appcasts.
+func (c *Client) Appcast(ctx context.Context, url string) (*appcast.Appcast, error) {
+	resp, err := c.Get(ctx, url, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	a, err := appcast.Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, &ParseError{Err: err}
+	}
+	return a, nil
+}
+
+// Latest returns the newest version in the appcast with an enclosure
+// WinSparkle would install on the system identified by the sparkle:os value
+// osName, e.g. "windows-x64". Items use their first matching enclosure.
+func Latest(a *appcast.Appcast, osName string) (version string, enc *appcast.Enclosure) {
+	for i := range a.Channel.Items {
+		item := &a.Channel.Items[i]
+		for j := range item.Enclosures {
+			e := &item.Enclosures[j]
+			if e.OS != "" && e.OS != "windows" && e.OS != osName {
+				continue
+			}
+			v := e.Version
+			if v == "" {
+				v = item.Version
+			}
+			if enc == nil || appcast.CompareVersions(v, version) > 0 {
+				version, enc = v, e
+			}
+			break
+		}
+	}
+	return version, enc
+}
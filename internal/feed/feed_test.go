@@ -0,0 +1,85 @@
+package feed_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abemedia/go-winsparkle/appcast"
+	"github.com/abemedia/go-winsparkle/internal/feed"
+)
+
+func TestClientAppcast(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "App/1.0 WinSparkle" || r.Header.Get("X-Test") != "1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/appcast.xml":
+			(&appcast.Appcast{}).Encode(w)
+		case "/invalid.xml":
+			w.Write([]byte("<rss>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	c := &feed.Client{
+		HTTP:      s.Client(),
+		UserAgent: feed.UserAgent("App", "1.0"),
+		Headers:   [][2]string{{"X-Test", "1"}},
+	}
+
+	if _, err := c.Appcast(context.Background(), s.URL+"/appcast.xml"); err != nil {
+		t.Error(err)
+	}
+
+	var parseErr *feed.ParseError
+	if _, err := c.Appcast(context.Background(), s.URL+"/invalid.xml"); !errors.As(err, &parseErr) {
+		t.Errorf("should fail to parse: %v", err)
+	}
+
+	var statusErr *feed.StatusError
+	if _, err := c.Appcast(context.Background(), s.URL+"/missing.xml"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("should fail with status: %v", err)
+	}
+
+	c.Headers = nil
+	if _, err := c.Get(context.Background(), s.URL+"/appcast.xml", false); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Errorf("should not send headers: %v", err)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	a := &appcast.Appcast{Channel: appcast.Channel{Items: []appcast.Item{
+		{Version: "1.0", Enclosures: []appcast.Enclosure{{URL: "1.0"}}},
+		{Version: "3.0", Enclosures: []appcast.Enclosure{{URL: "3.0-arm64", OS: "windows-arm64"}}},
+		{Enclosures: []appcast.Enclosure{
+			{URL: "2.0-x86", Version: "2.0", OS: "windows-x86"},
+			{URL: "2.0-x64", Version: "2.0", OS: "windows-x64"},
+			{URL: "2.0", Version: "2.0"},
+		}},
+	}}}
+
+	tests := []struct {
+		os, version, url string
+	}{
+		{"windows-x64", "2.0", "2.0-x64"},
+		{"windows-x86", "2.0", "2.0-x86"},
+		{"windows-arm64", "3.0", "3.0-arm64"},
+	}
+	for _, test := range tests {
+		version, enc := feed.Latest(a, test.os)
+		if version != test.version || enc == nil || enc.URL != test.url {
+			t.Errorf("%s: unexpected result %q, %+v", test.os, version, enc)
+		}
+	}
+
+	if _, enc := feed.Latest(&appcast.Appcast{}, "windows-x64"); enc != nil {
+		t.Error("should not find enclosure")
+	}
+}
@@ -0,0 +1,67 @@
+package winsparkle
+
+import "errors"
+
+const dllName = "WinSparkle.dll"
+
+// Feature is a WinSparkle feature which may be missing from older versions of
+// WinSparkle.dll. Functions using a missing feature return a [*CallError].
+type Feature string
+
+// Features which were added to WinSparkle after its initial release.
+const (
+	FeatureDSA                         Feature = "win_sparkle_set_dsa_pub_pem"
+	FeatureEdDSA                       Feature = "win_sparkle_set_eddsa_public_key"
+	FeatureAppBuildVersion             Feature = "win_sparkle_set_app_build_version"
+	FeatureHTTPHeaders                 Feature = "win_sparkle_set_http_header"
+	FeatureRegistryPath                Feature = "win_sparkle_set_registry_path"
+	FeatureConfigMethods               Feature = "win_sparkle_set_config_methods"
+	FeatureLangID                      Feature = "win_sparkle_set_langid"
+	FeatureCheckUpdateWithUIAndInstall Feature = "win_sparkle_check_update_with_ui_and_install"
+	FeatureErrorCallback               Feature = "win_sparkle_set_error_callback"
+	FeatureDidFindUpdateCallback       Feature = "win_sparkle_set_did_find_update_callback"
+	FeatureDidNotFindUpdateCallback    Feature = "win_sparkle_set_did_not_find_update_callback"
+	FeatureUpdateCancelledCallback     Feature = "win_sparkle_set_update_cancelled_callback"
+	FeatureUpdateSkippedCallback       Feature = "win_sparkle_set_update_skipped_callback"
+	FeatureUpdatePostponedCallback     Feature = "win_sparkle_set_update_postponed_callback"
+	FeatureUpdateDismissedCallback     Feature = "win_sparkle_set_update_dismissed_callback"
+	FeatureUserRunInstallerCallback    Feature = "win_sparkle_set_user_run_installer_callback"
+)
+
+// Load loads WinSparkle.dll. It returns a [*CallError] if the DLL can't be
+// found or loaded and [ErrUnsupported] on platforms other than Windows.
+//
+// Calling Load is optional as the DLL is loaded on first use, but it allows
+// detecting a missing DLL on startup.
+func Load() error {
+	if err := winsparkle.Load(); err != nil {
+		if errors.Is(err, ErrUnsupported) {
+			return err
+		}
+		return &CallError{Func: dllName, Err: err}
+	}
+	return nil
+}
+
+// Available reports whether WinSparkle.dll can be loaded.
+func Available() bool {
+	return Load() == nil
+}
+
+// Supports reports whether the loaded WinSparkle.dll supports the feature. It
+// returns false if the DLL can't be loaded.
+//
+// Use it to degrade gracefully if an outdated DLL is shipped next to the
+// executable, e.g.:
+//
+//	if winsparkle.Supports(winsparkle.FeatureEdDSA) {
+//		err = winsparkle.SetEdDSAPublicKey(key)
+//	} else {
+//		err = winsparkle.SetDSAPubPEM(pem)
+//	}
+func Supports(f Feature) bool {
+	if p, ok := procs[string(f)]; ok {
+		return p.find() == nil
+	}
+	return winsparkle.NewProc(string(f)).Find() == nil
+}
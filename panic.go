@@ -0,0 +1,71 @@
+package winsparkle
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+// PanicError is passed to the panic handler if a function called by
+// WinSparkle panics, e.g. a callback or a method of a [ConfigStore].
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("winsparkle: panic in callback: %v", e.Value)
+}
+
+var (
+	panicMu      sync.Mutex
+	panicHandler = defaultPanicHandler
+)
+
+func defaultPanicHandler(err *PanicError) {
+	log.Printf("%v\n%s", err, err.Stack)
+}
+
+// SetPanicHandler sets the function called if a function called by
+// WinSparkle panics. A nil handler restores the default, which logs the panic
+// using the [log] package.
+//
+// A panic must not unwind into WinSparkle, which would crash the application,
+// so it's recovered and WinSparkle receives a safe default instead: the
+// application can't shut down, the installer wasn't handled by the
+// application and config values can't be read or written.
+func SetPanicHandler(fn func(err *PanicError)) {
+	if fn == nil {
+		fn = defaultPanicHandler
+	}
+	panicMu.Lock()
+	panicHandler = fn
+	panicMu.Unlock()
+}
+
+// protect calls fn and reports whether it returned without panicking. A panic
+// is recovered and passed to the panic handler.
+func protect(fn func()) (ok bool) {
+	defer func() {
+		if !ok {
+			handlePanic(&PanicError{Value: recover(), Stack: debug.Stack()})
+		}
+	}()
+	fn()
+	return true
+}
+
+// handlePanic calls the panic handler. A panic in the handler is ignored as
+// it must not unwind into WinSparkle either.
+func handlePanic(err *PanicError) {
+	defer func() { _ = recover() }()
+
+	panicMu.Lock()
+	fn := panicHandler
+	panicMu.Unlock()
+	fn(err)
+}
@@ -0,0 +1,86 @@
+package winsparkle
+
+import (
+	"strings"
+	"syscall"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	var panics []any
+	SetPanicHandler(func(err *PanicError) {
+		panics = append(panics, err.Value)
+		if len(err.Stack) == 0 {
+			t.Error("missing stack trace")
+		}
+		panic("handler") // Ignored.
+	})
+	defer SetPanicHandler(nil)
+
+	t.Run("event", func(t *testing.T) {
+		h := testHook[func()](procSetDidFindUpdateCallback)
+		called := false
+		h.addLocked(func() { panic("event") })
+		h.addLocked(func() { called = true })
+		dispatchEvent(h, EventDidFindUpdate)
+		if !called {
+			t.Error("should call remaining handlers")
+		}
+	})
+
+	t.Run("can shutdown", func(t *testing.T) {
+		h := testHook[func() bool](procSetCanShutdownCallback)
+		h.addLocked(func() bool { panic("can shutdown") })
+		if canShutdown(h) {
+			t.Error("should not shut down")
+		}
+	})
+
+	t.Run("user run installer", func(t *testing.T) {
+		h := testHook[func(string) (bool, error)](procSetUserRunInstallerCallback)
+		h.addLocked(func(string) (bool, error) { panic("user run installer") })
+		if r := userRunInstaller(h, "update.exe"); r != 0 {
+			t.Errorf("should not handle installer: %d", r)
+		}
+	})
+
+	t.Run("config", func(t *testing.T) {
+		store := panicStore{}
+		name, _ := syscall.BytePtrFromString("name")
+		value := append(utf16.Encode([]rune("value")), 0)
+		buf := make([]uint16, 10)
+		if configRead(store, name, &buf[0], uintptr(len(buf))) != 0 {
+			t.Error("should not read value")
+		}
+		if configWrite(store, name, &value[0]) != 0 {
+			t.Error("should not write value")
+		}
+		if configDelete(store, name) != 0 {
+			t.Error("should not delete value")
+		}
+	})
+
+	want := []any{"event", "can shutdown", "user run installer", "read", "write", "delete"}
+	if len(panics) != len(want) {
+		t.Fatalf("got %v want %v", panics, want)
+	}
+	for i := range want {
+		if panics[i] != want[i] {
+			t.Errorf("got %v want %v", panics[i], want[i])
+		}
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	err := &PanicError{Value: "boom"}
+	if !strings.Contains(err.Error(), "boom") {
+		t.Errorf("unexpected message: %q", err.Error())
+	}
+}
+
+type panicStore struct{}
+
+func (panicStore) Read(string) (string, bool) { panic("read") }
+func (panicStore) Write(string, string) bool  { panic("write") }
+func (panicStore) Delete(string) bool         { panic("delete") }
@@ -0,0 +1,83 @@
+package winsparkle
+
+import "errors"
+
+// proc is a function exported by WinSparkle.dll. Its address is resolved on
+// first use and cached.
+type proc struct {
+	name string
+	lazy lazyProc
+}
+
+// procs contains all functions exported by WinSparkle.dll by name.
+var procs = map[string]*proc{}
+
+// Functions exported by WinSparkle.dll in the order of winsparkle.h.
+var (
+	procInit                        = newProc("win_sparkle_init")
+	procCleanup                     = newProc("win_sparkle_cleanup")
+	procSetLang                     = newProc("win_sparkle_set_lang")
+	procSetLangID                   = newProc("win_sparkle_set_langid")
+	procSetAppcastURL               = newProc("win_sparkle_set_appcast_url")
+	procSetAppDetails               = newProc("win_sparkle_set_app_details")
+	procSetAppBuildVersion          = newProc("win_sparkle_set_app_build_version")
+	procSetHTTPHeader               = newProc("win_sparkle_set_http_header")
+	procClearHTTPHeaders            = newProc("win_sparkle_clear_http_headers")
+	procSetRegistryPath             = newProc("win_sparkle_set_registry_path")
+	procSetConfigMethods            = newProc("win_sparkle_set_config_methods")
+	procSetDSAPubPEM                = newProc("win_sparkle_set_dsa_pub_pem")
+	procSetEdDSAPublicKey           = newProc("win_sparkle_set_eddsa_public_key")
+	procSetAutomaticCheckForUpdates = newProc("win_sparkle_set_automatic_check_for_updates")
+	procGetAutomaticCheckForUpdates = newProc("win_sparkle_get_automatic_check_for_updates")
+	procSetUpdateCheckInterval      = newProc("win_sparkle_set_update_check_interval")
+	procGetUpdateCheckInterval      = newProc("win_sparkle_get_update_check_interval")
+	procGetLastCheckTime            = newProc("win_sparkle_get_last_check_time")
+	procSetErrorCallback            = newProc("win_sparkle_set_error_callback")
+	procSetCanShutdownCallback      = newProc("win_sparkle_set_can_shutdown_callback")
+	procSetShutdownRequestCallback  = newProc("win_sparkle_set_shutdown_request_callback")
+	procSetDidFindUpdateCallback    = newProc("win_sparkle_set_did_find_update_callback")
+	procSetDidNotFindUpdateCallback = newProc("win_sparkle_set_did_not_find_update_callback")
+	procSetUpdateCancelledCallback  = newProc("win_sparkle_set_update_cancelled_callback")
+	procSetUpdateSkippedCallback    = newProc("win_sparkle_set_update_skipped_callback")
+	procSetUpdatePostponedCallback  = newProc("win_sparkle_set_update_postponed_callback")
+	procSetUpdateDismissedCallback  = newProc("win_sparkle_set_update_dismissed_callback")
+	procSetUserRunInstallerCallback = newProc("win_sparkle_set_user_run_installer_callback")
+	procCheckUpdateWithUI           = newProc("win_sparkle_check_update_with_ui")
+	procCheckUpdateWithUIAndInstall = newProc("win_sparkle_check_update_with_ui_and_install")
+	procCheckUpdateWithoutUI        = newProc("win_sparkle_check_update_without_ui")
+)
+
+func newProc(name string) *proc {
+	p := &proc{name: name, lazy: winsparkle.NewProc(name)}
+	procs[name] = p
+	return p
+}
+
+// find resolves the address of the function. It returns a [*CallError] if
+// WinSparkle.dll or the function can't be loaded and [ErrUnsupported] on
+// platforms other than Windows.
+func (p *proc) find() error {
+	if err := p.lazy.Find(); err != nil {
+		if errors.Is(err, ErrUnsupported) {
+			return err
+		}
+		return &CallError{Func: p.name, Err: err}
+	}
+	return nil
+}
+
+// call calls the function. It returns the same errors as [proc.find].
+//
+// Pointers passed as arguments must be converted to uintptr in the call
+// expression to keep them alive for the duration of the call.
+//
+//go:uintptrescapes
+func (p *proc) call(args ...uintptr) (r1, r2 uintptr, err error) {
+	if err = p.find(); err != nil {
+		return 0, 0, err
+	}
+	// The error returned by Call is the result of GetLastError, which
+	// WinSparkle doesn't set.
+	r1, r2, _ = p.lazy.Call(args...)
+	return r1, r2, nil
+}
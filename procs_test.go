@@ -0,0 +1,29 @@
+package winsparkle
+
+import "testing"
+
+func TestFeatureProcs(t *testing.T) {
+	features := []Feature{
+		FeatureDSA,
+		FeatureEdDSA,
+		FeatureAppBuildVersion,
+		FeatureHTTPHeaders,
+		FeatureRegistryPath,
+		FeatureConfigMethods,
+		FeatureLangID,
+		FeatureCheckUpdateWithUIAndInstall,
+		FeatureErrorCallback,
+		FeatureDidFindUpdateCallback,
+		FeatureDidNotFindUpdateCallback,
+		FeatureUpdateCancelledCallback,
+		FeatureUpdateSkippedCallback,
+		FeatureUpdatePostponedCallback,
+		FeatureUpdateDismissedCallback,
+		FeatureUserRunInstallerCallback,
+	}
+	for _, f := range features {
+		if _, ok := procs[string(f)]; !ok {
+			t.Errorf("missing proc for feature %s", f)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package sign
+
+import (
+	"crypto/dsa" //nolint:staticcheck // DSA is required for legacy WinSparkle clients.
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+)
+
+var oidDSA = asn1.ObjectIdentifier{1, 2, 840, 10040, 4, 1}
+
+// ParseDSAPrivateKey parses a PEM encoded DSA private key as created by
+// scripts/generate_keys.bat.
+//
+// Both the traditional OpenSSL ("DSA PRIVATE KEY") and the PKCS #8
+// ("PRIVATE KEY") formats are supported.
+func ParseDSAPrivateKey(key string) (*dsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, errors.New("invalid DSA private key: no PEM data found")
+	}
+
+	switch block.Type {
+	case "DSA PRIVATE KEY":
+		var k struct {
+			Version       int
+			P, Q, G, Y, X *big.Int
+		}
+		if _, err := asn1.Unmarshal(block.Bytes, &k); err != nil {
+			return nil, errors.New("invalid DSA private key: " + err.Error())
+		}
+		return &dsa.PrivateKey{
+			PublicKey: dsa.PublicKey{Parameters: dsa.Parameters{P: k.P, Q: k.Q, G: k.G}, Y: k.Y},
+			X:         k.X,
+		}, nil
+
+	case "PRIVATE KEY":
+		var k struct {
+			Version int
+			Algo    struct {
+				Algorithm  asn1.ObjectIdentifier
+				Parameters struct{ P, Q, G *big.Int }
+			}
+			PrivateKey []byte
+		}
+		if _, err := asn1.Unmarshal(block.Bytes, &k); err != nil {
+			return nil, errors.New("invalid DSA private key: " + err.Error())
+		}
+		if !k.Algo.Algorithm.Equal(oidDSA) {
+			return nil, errors.New("invalid DSA private key: not a DSA key")
+		}
+		x := new(big.Int)
+		if _, err := asn1.Unmarshal(k.PrivateKey, &x); err != nil {
+			return nil, errors.New("invalid DSA private key: " + err.Error())
+		}
+		params := dsa.Parameters{P: k.Algo.Parameters.P, Q: k.Algo.Parameters.Q, G: k.Algo.Parameters.G}
+		return &dsa.PrivateKey{
+			PublicKey: dsa.PublicKey{Parameters: params, Y: new(big.Int).Exp(params.G, x, params.P)},
+			X:         x,
+		}, nil
+
+	default:
+		return nil, errors.New("invalid DSA private key: unexpected PEM type " + block.Type)
+	}
+}
+
+// ParseDSAPublicKey parses a PEM encoded DSA public key as accepted by
+// winsparkle.SetDSAPubPEM.
+func ParseDSAPublicKey(key string) (*dsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, errors.New("invalid DSA public key: no PEM data found")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("invalid DSA public key: " + err.Error())
+	}
+	k, ok := pub.(*dsa.PublicKey)
+	if !ok {
+		return nil, errors.New("invalid DSA public key: not a DSA key")
+	}
+	return k, nil
+}
+
+// SignDSA signs the update payload read from r with the PEM encoded DSA
+// private key, the same way as scripts/sign_update.bat does.
+//
+// It returns the base64 encoded signature for the appcast's
+// sparkle:dsaSignature attribute.
+//
+// Note: DSA signatures are deprecated by WinSparkle. Use [SignEdDSA] instead.
+// Appcasts may carry both signatures while migrating.
+func SignDSA(privateKey string, r io.Reader) (string, error) {
+	key, err := ParseDSAPrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	digest, err := dsaDigest(r)
+	if err != nil {
+		return "", err
+	}
+	rr, ss, err := dsa.Sign(rand.Reader, key, digest)
+	if err != nil {
+		return "", err
+	}
+	sig, err := asn1.Marshal(struct{ R, S *big.Int }{rr, ss})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// VerifyDSA verifies the base64 encoded DSA signature of the update payload
+// read from r against the PEM encoded public key, as passed to
+// winsparkle.SetDSAPubPEM.
+//
+// It returns [ErrInvalidSignature] if the signature doesn't match.
+func VerifyDSA(publicKey string, r io.Reader, signature string) error {
+	key, err := ParseDSAPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return errors.New("invalid DSA signature: " + err.Error())
+	}
+	var sig struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(b, &sig); err != nil || len(rest) != 0 {
+		return ErrInvalidSignature
+	}
+	digest, err := dsaDigest(r)
+	if err != nil {
+		return err
+	}
+	if !dsa.Verify(key, digest, sig.R, sig.S) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// dsaDigest returns the SHA1 hash of the SHA1 hash of the payload, which is
+// what WinSparkle verifies DSA signatures against.
+func dsaDigest(r io.Reader) ([]byte, error) {
+	h := sha1.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	digest := sha1.Sum(h.Sum(nil))
+	return digest[:], nil
+}
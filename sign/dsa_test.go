@@ -0,0 +1,60 @@
+package sign_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/abemedia/go-winsparkle/sign"
+)
+
+// Signature of "hello\n" created with scripts/sign_update.bat and testdata/dsa_priv.pem.
+const opensslSignature = "MD4CHQCVxv+KoSRPBQjuVtyVUZH771u754CYHPbSM2SmAh0AtaL7qTiK8UmbLG7Wg9OQfO/j1bCRVGWXDTS/Wg=="
+
+func TestVerifyDSA(t *testing.T) {
+	pub := readFile(t, "testdata/dsa_pub.pem")
+
+	if err := sign.VerifyDSA(pub, strings.NewReader("hello\n"), opensslSignature); err != nil {
+		t.Errorf("should verify: %v", err)
+	}
+	err := sign.VerifyDSA(pub, strings.NewReader("tampered\n"), opensslSignature)
+	if !errors.Is(err, sign.ErrInvalidSignature) {
+		t.Errorf("tampered payload: unexpected error: %v", err)
+	}
+}
+
+func TestSignDSA(t *testing.T) {
+	pub := readFile(t, "testdata/dsa_pub.pem")
+
+	for _, file := range []string{"testdata/dsa_priv.pem", "testdata/dsa_priv_trad.pem"} {
+		sig, err := sign.SignDSA(readFile(t, file), strings.NewReader("hello\n"))
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if err = sign.VerifyDSA(pub, strings.NewReader("hello\n"), sig); err != nil {
+			t.Errorf("%s: should verify: %v", file, err)
+		}
+	}
+}
+
+func TestParseDSAKeyInvalid(t *testing.T) {
+	if _, err := sign.ParseDSAPrivateKey(readFile(t, "testdata/dsa_pub.pem")); err == nil {
+		t.Error("private key: should fail")
+	}
+	if _, err := sign.ParseDSAPublicKey(readFile(t, "testdata/dsa_priv.pem")); err == nil {
+		t.Error("public key: should fail")
+	}
+	if _, err := sign.ParseDSAPublicKey("not PEM"); err == nil {
+		t.Error("not PEM: should fail")
+	}
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,107 @@
+// Package sign creates and verifies signatures of WinSparkle update payloads.
+//
+// This package doesn't depend on WinSparkle.dll and can be used on any
+// platform, e.g. to sign updates as part of a release pipeline.
+package sign
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrInvalidSignature is returned if a signature doesn't match the update
+// payload.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// GenerateEdDSAKey generates a new EdDSA (ed25519) key pair.
+//
+// The public key is returned base64 encoded, as accepted by
+// winsparkle.SetEdDSAPublicKey. The private key is returned as the base64
+// encoded ed25519 seed.
+func GenerateEdDSAKey() (publicKey, privateKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	publicKey = base64.StdEncoding.EncodeToString(pub)
+	privateKey = base64.StdEncoding.EncodeToString(priv.Seed())
+	return publicKey, privateKey, nil
+}
+
+// ParseEdDSAPrivateKey parses a base64 encoded EdDSA (ed25519) private key.
+//
+// Both the 32 byte seed and the 64 byte private key format are supported.
+func ParseEdDSAPrivateKey(key string) (ed25519.PrivateKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, errors.New("invalid EdDSA private key: " + err.Error())
+	}
+	switch len(b) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(b), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(b), nil
+	default:
+		return nil, errors.New("invalid EdDSA private key: unexpected length")
+	}
+}
+
+// ParseEdDSAPublicKey parses a base64 encoded EdDSA (ed25519) public key as
+// accepted by winsparkle.SetEdDSAPublicKey.
+func ParseEdDSAPublicKey(key string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, errors.New("invalid EdDSA public key: " + err.Error())
+	}
+	if len(b) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid EdDSA public key: unexpected length")
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// SignEdDSA signs the update payload read from r with the base64 encoded
+// EdDSA (ed25519) private key.
+//
+// It returns the base64 encoded signature for the appcast's
+// sparkle:edSignature attribute and the payload's length in bytes for the
+// enclosure's length attribute.
+func SignEdDSA(privateKey string, r io.Reader) (signature string, length int64, err error) {
+	key, err := ParseEdDSAPrivateKey(privateKey)
+	if err != nil {
+		return "", 0, err
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", 0, err
+	}
+	signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, b))
+	return signature, int64(len(b)), nil
+}
+
+// VerifyEdDSA verifies the base64 encoded EdDSA (ed25519) signature of the
+// update payload read from r against the base64 encoded public key, as passed
+// to winsparkle.SetEdDSAPublicKey.
+//
+// It returns [ErrInvalidSignature] if the signature doesn't match.
+func VerifyEdDSA(publicKey string, r io.Reader, signature string) error {
+	key, err := ParseEdDSAPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return errors.New("invalid EdDSA signature: " + err.Error())
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, b, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package sign_test
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/abemedia/go-winsparkle/sign"
+)
+
+func TestGenerateEdDSAKey(t *testing.T) {
+	pub, priv, err := sign.GenerateEdDSAKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, err := sign.ParseEdDSAPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := sign.ParseEdDSAPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !publicKey.Equal(privateKey.Public()) {
+		t.Error("public key doesn't match private key")
+	}
+}
+
+func TestParseEdDSAKeyInvalid(t *testing.T) {
+	for _, key := range []string{"", "not base64", "c2hvcnQ="} {
+		if _, err := sign.ParseEdDSAPublicKey(key); err == nil {
+			t.Errorf("public key %q: should fail", key)
+		}
+		if _, err := sign.ParseEdDSAPrivateKey(key); err == nil {
+			t.Errorf("private key %q: should fail", key)
+		}
+	}
+}
+
+func TestSignEdDSA(t *testing.T) {
+	pub, priv, err := sign.GenerateEdDSAKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, _ := sign.ParseEdDSAPublicKey(pub)
+
+	const payload = "update payload"
+	sig, length, err := sign.SignEdDSA(priv, strings.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if length != int64(len(payload)) {
+		t.Errorf("unexpected length: %d", length)
+	}
+
+	b, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(publicKey, []byte(payload), b) {
+		t.Error("signature should be valid")
+	}
+}
+
+func TestVerifyEdDSA(t *testing.T) {
+	pub, priv, err := sign.GenerateEdDSAKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := sign.GenerateEdDSAKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const payload = "update payload"
+	sig, _, err := sign.SignEdDSA(priv, strings.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = sign.VerifyEdDSA(pub, strings.NewReader(payload), sig); err != nil {
+		t.Errorf("should verify: %v", err)
+	}
+	if err = sign.VerifyEdDSA(pub, strings.NewReader("tampered"), sig); !errors.Is(err, sign.ErrInvalidSignature) {
+		t.Errorf("tampered payload: unexpected error: %v", err)
+	}
+	if err = sign.VerifyEdDSA(otherPub, strings.NewReader(payload), sig); !errors.Is(err, sign.ErrInvalidSignature) {
+		t.Errorf("wrong key: unexpected error: %v", err)
+	}
+	if err = sign.VerifyEdDSA(pub, strings.NewReader(payload), "not base64"); err == nil {
+		t.Error("invalid signature: should fail")
+	}
+}
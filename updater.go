@@ -0,0 +1,121 @@
+package winsparkle
+
+import (
+	"context"
+	"time"
+)
+
+// Updater is the interface implemented by WinSparkle.
+//
+// It mirrors the package-level functions, allowing applications to accept an
+// Updater and inject a fake in tests. Use [Default] for the implementation
+// backed by WinSparkle.dll.
+type Updater interface {
+	Init() error
+	Cleanup() error
+
+	SetLang(lang string) error
+	SetLangID(langid uint16) error
+	SetAppcastURL(url string) error
+	SetDSAPubPEM(pem string) error
+	SetEdDSAPublicKey(key string) error
+	SetAppDetails(company, app, version string) error
+	SetAppBuildVersion(build string) error
+	SetHTTPHeader(name, value string) error
+	ClearHTTPHeaders() error
+	SetRegistryPath(path string) error
+	SetConfigMethods(store ConfigStore) error
+	SetAutomaticCheckForUpdates(check bool) error
+	GetAutomaticCheckForUpdates() bool
+	SetUpdateCheckInterval(interval time.Duration) error
+	GetUpdateCheckInterval() time.Duration
+	GetLastCheckTime() time.Time
+
+	SetErrorCallback(cb func()) error
+	SetErrorHandler(fn func(err error)) error
+	SetCanShutdownCallback(cb func() bool) error
+	SetShutdownRequestCallback(cb func()) error
+	SetDidFindUpdateCallback(cb func()) error
+	SetDidNotFindUpdateCallback(cb func()) error
+	SetUpdateCancelledCallback(cb func()) error
+	SetUpdateSkippedCallback(cb func()) error
+	SetUpdatePostponedCallback(cb func()) error
+	SetUpdateDismissedCallback(cb func()) error
+	SetUserRunInstallerCallback(cb func(file string) (handled bool, err error)) error
+	Subscribe(buffer int) (events <-chan Event, unsubscribe func())
+	AddListener(kind EventKind, fn func()) (remove func(), err error)
+	AddCanShutdownListener(fn func() bool) (remove func(), err error)
+	AddUserRunInstallerListener(fn func(file string) (handled bool, err error)) (remove func(), err error)
+
+	CheckUpdateWithUI() error
+	CheckUpdateWithUIAndInstall() error
+	CheckUpdateWithoutUI() error
+	CheckForUpdate(ctx context.Context) (Result, error)
+	CheckForUpdateWithUI(ctx context.Context) (Result, error)
+}
+
+// Default is the [Updater] backed by WinSparkle.dll. Its methods call the
+// package-level functions of the same name.
+var Default Updater = dllUpdater{}
+
+type dllUpdater struct{}
+
+func (dllUpdater) Init() error                        { return Init() }
+func (dllUpdater) Cleanup() error                     { return Cleanup() }
+func (dllUpdater) SetLang(lang string) error          { return SetLang(lang) }
+func (dllUpdater) SetLangID(langid uint16) error      { return SetLangID(langid) }
+func (dllUpdater) SetAppcastURL(url string) error     { return SetAppcastURL(url) }
+func (dllUpdater) SetDSAPubPEM(pem string) error      { return SetDSAPubPEM(pem) }
+func (dllUpdater) SetEdDSAPublicKey(key string) error { return SetEdDSAPublicKey(key) }
+func (dllUpdater) SetAppDetails(company, app, version string) error {
+	return SetAppDetails(company, app, version)
+}
+func (dllUpdater) SetAppBuildVersion(build string) error    { return SetAppBuildVersion(build) }
+func (dllUpdater) SetHTTPHeader(name, value string) error   { return SetHTTPHeader(name, value) }
+func (dllUpdater) ClearHTTPHeaders() error                  { return ClearHTTPHeaders() }
+func (dllUpdater) SetRegistryPath(path string) error        { return SetRegistryPath(path) }
+func (dllUpdater) SetConfigMethods(store ConfigStore) error { return SetConfigMethods(store) }
+func (dllUpdater) SetAutomaticCheckForUpdates(check bool) error {
+	return SetAutomaticCheckForUpdates(check)
+}
+func (dllUpdater) GetAutomaticCheckForUpdates() bool { return GetAutomaticCheckForUpdates() }
+func (dllUpdater) SetUpdateCheckInterval(interval time.Duration) error {
+	return SetUpdateCheckInterval(interval)
+}
+func (dllUpdater) GetUpdateCheckInterval() time.Duration { return GetUpdateCheckInterval() }
+func (dllUpdater) GetLastCheckTime() time.Time           { return GetLastCheckTime() }
+
+func (dllUpdater) SetErrorCallback(cb func()) error            { return SetErrorCallback(cb) }
+func (dllUpdater) SetErrorHandler(fn func(err error)) error    { return SetErrorHandler(fn) }
+func (dllUpdater) SetCanShutdownCallback(cb func() bool) error { return SetCanShutdownCallback(cb) }
+func (dllUpdater) SetShutdownRequestCallback(cb func()) error  { return SetShutdownRequestCallback(cb) }
+func (dllUpdater) SetDidFindUpdateCallback(cb func()) error    { return SetDidFindUpdateCallback(cb) }
+func (dllUpdater) SetDidNotFindUpdateCallback(cb func()) error {
+	return SetDidNotFindUpdateCallback(cb)
+}
+func (dllUpdater) SetUpdateCancelledCallback(cb func()) error { return SetUpdateCancelledCallback(cb) }
+func (dllUpdater) SetUpdateSkippedCallback(cb func()) error   { return SetUpdateSkippedCallback(cb) }
+func (dllUpdater) SetUpdatePostponedCallback(cb func()) error { return SetUpdatePostponedCallback(cb) }
+func (dllUpdater) SetUpdateDismissedCallback(cb func()) error { return SetUpdateDismissedCallback(cb) }
+func (dllUpdater) SetUserRunInstallerCallback(cb func(file string) (handled bool, err error)) error {
+	return SetUserRunInstallerCallback(cb)
+}
+func (dllUpdater) Subscribe(buffer int) (<-chan Event, func()) { return Subscribe(buffer) }
+func (dllUpdater) AddListener(kind EventKind, fn func()) (func(), error) {
+	return AddListener(kind, fn)
+}
+func (dllUpdater) AddCanShutdownListener(fn func() bool) (func(), error) {
+	return AddCanShutdownListener(fn)
+}
+func (dllUpdater) AddUserRunInstallerListener(fn func(file string) (handled bool, err error)) (func(), error) {
+	return AddUserRunInstallerListener(fn)
+}
+
+func (dllUpdater) CheckUpdateWithUI() error           { return CheckUpdateWithUI() }
+func (dllUpdater) CheckUpdateWithUIAndInstall() error { return CheckUpdateWithUIAndInstall() }
+func (dllUpdater) CheckUpdateWithoutUI() error        { return CheckUpdateWithoutUI() }
+
+func (dllUpdater) CheckForUpdate(ctx context.Context) (Result, error) { return CheckForUpdate(ctx) }
+func (dllUpdater) CheckForUpdateWithUI(ctx context.Context) (Result, error) {
+	return CheckForUpdateWithUI(ctx)
+}
@@ -1,5 +1,3 @@
-//go:build windows
-
 // Package winsparkle provides go bindings for WinSparkle.
 //
 // WinSparkle is a plug-and-forget software update library for Windows
@@ -8,17 +6,22 @@
 // updates format (appcasts) and having a very similar user interface.
 //
 // See https://winsparkle.org for more information about WinSparkle.
+//
+// Functions return a [*CallError] if WinSparkle.dll or the function can't be
+// loaded, e.g. because the DLL is missing or outdated, and an [*ArgError] if
+// a string argument can't be passed to WinSparkle. Getters return zero values
+// in these cases.
+//
+// WinSparkle only runs on Windows. The package builds on all platforms so it
+// can be used from cross-platform code, but on other platforms all functions
+// are no-ops returning [ErrUnsupported].
 package winsparkle
 
 import (
-	"errors"
-	"syscall"
 	"time"
 	"unsafe"
 )
 
-var winsparkle = syscall.NewLazyDLL("WinSparkle.dll")
-
 // Init starts WinSparkle.
 //
 // If WinSparkle is configured to check for updates on startup, proceeds
@@ -28,16 +31,19 @@ var winsparkle = syscall.NewLazyDLL("WinSparkle.dll")
 // This call doesn't block and returns almost immediately. If an
 // update is available, the respective UI is shown later from a separate
 // thread.
-func Init() {
-	winsparkle.NewProc("win_sparkle_init").Call()
+func Init() error {
+	_, _, err := procInit.call()
+	return err
 }
 
 // Cleanup cleans up after WinSparkle.
 //
 // Should be called by the app when it's shutting down. Cancels any
 // pending Sparkle operations and shuts down its helper threads.
-func Cleanup() {
-	winsparkle.NewProc("win_sparkle_cleanup").Call()
+func Cleanup() error {
+	diag.stop()
+	_, _, err := procCleanup.call()
+	return err
 }
 
 // SetLang sets UI language from its ISO code.
@@ -47,8 +53,13 @@ func Cleanup() {
 // Param lang must be an ISO 639 language code with an optional ISO 3116
 // country code, e.g. "fr", "pt-PT", "pt-BR" or "pt_BR", as used
 // e.g. by ::GetThreadPreferredUILanguages() too.
-func SetLang(lang string) {
-	winsparkle.NewProc("win_sparkle_set_lang").Call(char(lang))
+func SetLang(lang string) error {
+	p, err := char("lang", lang)
+	if err != nil {
+		return err
+	}
+	_, _, err = procSetLang.call(uintptr(unsafe.Pointer(p)))
+	return err
 }
 
 // SetLangID sets UI language from its Win32 LANGID code.
@@ -59,8 +70,9 @@ func SetLang(lang string) {
 // macro or returned by e.g. ::GetThreadUILanguage().
 //
 // See https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-lcid/
-func SetLangID(langid uint16) {
-	winsparkle.NewProc("win_sparkle_set_langid").Call(uintptr(langid))
+func SetLangID(langid uint16) error {
+	_, _, err := procSetLangID.call(uintptr(langid))
+	return err
 }
 
 // SetAppcastURL sets URL for the app's appcast.
@@ -76,8 +88,16 @@ func SetLangID(langid uint16) {
 //
 // Note: See https://github.com/vslavik/winsparkle/wiki/Appcast-Feeds for
 // more information about appcast feeds.
-func SetAppcastURL(url string) {
-	winsparkle.NewProc("win_sparkle_set_appcast_url").Call(char(url))
+func SetAppcastURL(url string) error {
+	p, err := char("url", url)
+	if err != nil {
+		return err
+	}
+	if _, _, err = procSetAppcastURL.call(uintptr(unsafe.Pointer(p))); err != nil {
+		return err
+	}
+	diag.set(func(s *diagState) { s.appcastURL = url })
+	return nil
 }
 
 // SetDSAPubPEM sets DSA public key.
@@ -94,10 +114,18 @@ func SetAppcastURL(url string) {
 // Migrate over to EdDSA (ed25519) using [SetEdDSAPublicKey], see
 // https://github.com/vslavik/winsparkle/wiki/Upgrading-from-DSA-to-EdDSA-signatures.
 func SetDSAPubPEM(pem string) error {
-	r, _, _ := winsparkle.NewProc("win_sparkle_set_dsa_pub_pem").Call(char(pem))
+	p, err := char("pem", pem)
+	if err != nil {
+		return err
+	}
+	r, _, err := procSetDSAPubPEM.call(uintptr(unsafe.Pointer(p)))
+	if err != nil {
+		return err
+	}
 	if r == 0 {
-		return errors.New("invalid DSA public key provided")
+		return ErrInvalidPublicKey
 	}
+	diag.set(func(s *diagState) { s.dsaPublicKey = pem })
 	return nil
 }
 
@@ -114,10 +142,18 @@ func SetDSAPubPEM(pem string) error {
 // Note: If this function is called, DSA public key set with [SetDSAPubPEM]
 // or present in the resources will be ignored; so will DSA signatures in the appcast.
 func SetEdDSAPublicKey(key string) error {
-	r, _, _ := winsparkle.NewProc("win_sparkle_set_eddsa_public_key").Call(char(key))
+	p, err := char("key", key)
+	if err != nil {
+		return err
+	}
+	r, _, err := procSetEdDSAPublicKey.call(uintptr(unsafe.Pointer(p)))
+	if err != nil {
+		return err
+	}
 	if r == 0 {
-		return errors.New("invalid edDSA public key provided")
+		return ErrInvalidPublicKey
 	}
+	diag.set(func(s *diagState) { s.edDSAPublicKey = key })
 	return nil
 }
 
@@ -131,8 +167,27 @@ func SetEdDSAPublicKey(key string) error {
 //
 // Note: `company` and `app` are used to determine the location of WinSparkle
 // settings in registry (HKCU\Software\<company>\<app>\WinSparkle is used).
-func SetAppDetails(company, app, version string) {
-	winsparkle.NewProc("win_sparkle_set_app_details").Call(wchar(company), wchar(app), wchar(version))
+func SetAppDetails(company, app, version string) error {
+	c, err := wchar("company", company)
+	if err != nil {
+		return err
+	}
+	a, err := wchar("app", app)
+	if err != nil {
+		return err
+	}
+	v, err := wchar("version", version)
+	if err != nil {
+		return err
+	}
+	_, _, err = procSetAppDetails.call(
+		uintptr(unsafe.Pointer(c)), uintptr(unsafe.Pointer(a)), uintptr(unsafe.Pointer(v)),
+	)
+	if err != nil {
+		return err
+	}
+	diag.set(func(s *diagState) { s.app, s.version = app, version })
+	return nil
 }
 
 // SetAppBuildVersion sets application build version number.
@@ -147,19 +202,40 @@ func SetAppDetails(company, app, version string) {
 // the appcast must also contain the "shortVersionString" attribute with
 // human-readable display version string. The version passed to [SetAppDetails]
 // corresponds to this and is used for display.
-func SetAppBuildVersion(build string) {
-	winsparkle.NewProc("win_sparkle_set_app_build_version").Call(wchar(build))
+func SetAppBuildVersion(build string) error {
+	p, err := wchar("build", build)
+	if err != nil {
+		return err
+	}
+	_, _, err = procSetAppBuildVersion.call(uintptr(unsafe.Pointer(p)))
+	return err
 }
 
 // SetHTTPHeader sets custom HTTP header for appcast checks.
-func SetHTTPHeader(name, value string) {
-	winsparkle.NewProc("win_sparkle_set_http_header").Call(char(name), char(value))
+func SetHTTPHeader(name, value string) error {
+	n, err := char("name", name)
+	if err != nil {
+		return err
+	}
+	v, err := char("value", value)
+	if err != nil {
+		return err
+	}
+	if _, _, err = procSetHTTPHeader.call(uintptr(unsafe.Pointer(n)), uintptr(unsafe.Pointer(v))); err != nil {
+		return err
+	}
+	diag.set(func(s *diagState) { s.headers = append(s.headers, [2]string{name, value}) })
+	return nil
 }
 
 // ClearHTTPHeaders clears all custom HTTP headers previously added using
 // [SetHTTPHeader].
-func ClearHTTPHeaders() {
-	winsparkle.NewProc("win_sparkle_clear_http_headers").Call()
+func ClearHTTPHeaders() error {
+	if _, _, err := procClearHTTPHeaders.call(); err != nil {
+		return err
+	}
+	diag.set(func(s *diagState) { s.headers = nil })
+	return nil
 }
 
 // SetRegistryPath sets the registry path where settings will be stored.
@@ -173,8 +249,13 @@ func ClearHTTPHeaders() {
 // of it. For example:
 //
 //	sparkle.SetRegistryPath("Software\\My App\\Updates");
-func SetRegistryPath(path string) {
-	winsparkle.NewProc("win_sparkle_set_registry_path").Call(char(path))
+func SetRegistryPath(path string) error {
+	p, err := char("path", path)
+	if err != nil {
+		return err
+	}
+	_, _, err = procSetRegistryPath.call(uintptr(unsafe.Pointer(p)))
+	return err
 }
 
 // ConfigStore is used to override WinSparkle configuration's read, write and delete
@@ -190,6 +271,15 @@ type ConfigStore interface {
 	Delete(name string) bool
 }
 
+// ConfigErrorHandler can be implemented by a [ConfigStore] to be notified of
+// values which couldn't be passed to WinSparkle. WinSparkle treats these as
+// missing and uses its defaults.
+type ConfigErrorHandler interface {
+	// ConfigError is called with the name of the value and [ErrValueTooLong]
+	// or [ErrInvalidValue].
+	ConfigError(name string, err error)
+}
+
 // SetConfigMethods overrides WinSparkle's configuration read, write and delete
 // functions.
 //
@@ -198,15 +288,18 @@ type ConfigStore interface {
 // If you want to manage configuration by yourself, or if you don't want let
 // WinSparkle write settings directly to the Windows Registry, you can provide
 // your own functions to read, write and delete configuration.
-func SetConfigMethods(store ConfigStore) {
-	winsparkle.NewProc("win_sparkle_set_config_methods").Call(uintptr(configMethods(store)))
+func SetConfigMethods(store ConfigStore) error {
+	m := setConfigStore(store)
+	_, _, err := procSetConfigMethods.call(uintptr(m))
+	return err
 }
 
 // SetAutomaticCheckForUpdates sets whether updates are checked automatically
 // or only through a manual call. If disabled, [CheckUpdateWithUI] must be used
 // explicitly.
-func SetAutomaticCheckForUpdates(check bool) {
-	winsparkle.NewProc("win_sparkle_set_automatic_check_for_updates").Call(boolean(check))
+func SetAutomaticCheckForUpdates(check bool) error {
+	_, _, err := procSetAutomaticCheckForUpdates.call(boolean(check))
+	return err
 }
 
 // GetAutomaticCheckForUpdates gets the automatic update checking state.
@@ -215,7 +308,7 @@ func SetAutomaticCheckForUpdates(check bool) {
 //
 // Note: Defaults to 0 when not yet configured (as happens on first start).
 func GetAutomaticCheckForUpdates() bool {
-	r, _, _ := winsparkle.NewProc("win_sparkle_get_automatic_check_for_updates").Call()
+	r, _, _ := procGetAutomaticCheckForUpdates.call()
 	return r == 1
 }
 
@@ -223,15 +316,16 @@ func GetAutomaticCheckForUpdates() bool {
 // updates.
 //
 // Note: The minimum update interval is 1 hour.
-func SetUpdateCheckInterval(interval time.Duration) {
-	winsparkle.NewProc("win_sparkle_set_update_check_interval").Call(uintptr(interval / time.Second))
+func SetUpdateCheckInterval(interval time.Duration) error {
+	_, _, err := procSetUpdateCheckInterval.call(uintptr(interval / time.Second))
+	return err
 }
 
 // GetUpdateCheckInterval gets the automatic update interval.
 //
 // Default value is one day.
 func GetUpdateCheckInterval() time.Duration {
-	r, _, _ := winsparkle.NewProc("win_sparkle_get_update_check_interval").Call()
+	r, _, _ := procGetUpdateCheckInterval.call()
 	return time.Duration(r) * time.Second
 }
 
@@ -239,7 +333,10 @@ func GetUpdateCheckInterval() time.Duration {
 //
 // Default value is the zero time, indicating that the update check has never run.
 func GetLastCheckTime() time.Time {
-	r1, r2, _ := winsparkle.NewProc("win_sparkle_get_last_check_time").Call()
+	r1, r2, err := procGetLastCheckTime.call()
+	if err != nil {
+		return time.Time{}
+	}
 	var t int64
 	if unsafe.Sizeof(uintptr(0)) == 8 {
 		t = int64(r1)
@@ -254,10 +351,37 @@ func GetLastCheckTime() time.Time {
 }
 
 // SetErrorCallback sets callback to be called when the updater encounters an
-// error.
-func SetErrorCallback(cb func()) {
-	fn := syscall.NewCallbackCDecl(func() uintptr { cb(); return 0 })
-	winsparkle.NewProc("win_sparkle_set_error_callback").Call(fn)
+// error. Use [SetErrorHandler] to receive the cause of the error.
+func SetErrorCallback(cb func()) error {
+	diag.setHandler(nil)
+	return setEventCallback(EventError, cb)
+}
+
+// SetErrorHandler sets a function called with an [*UpdateError] describing
+// the cause when the updater encounters an error. It replaces the callback set
+// by [SetErrorCallback] and vice versa.
+//
+// WinSparkle doesn't report the cause of errors, so the category is a
+// best-effort guess made by repeating WinSparkle's requests, which means an
+// extra request to the appcast server per check: the appcast is fetched
+// alongside WinSparkle when [CheckUpdateWithUI] or a similar function is
+// called, or after the error for automatic checks, and the update is
+// downloaded again if WinSparkle fails after finding it. This uses the
+// settings passed to [SetAppcastURL], [SetHTTPHeader], [SetEdDSAPublicKey]
+// and [SetDSAPubPEM]; settings read from the application's resources aren't
+// known. If the requests succeed or don't finish within 10 seconds, or the
+// update is larger than 256 MiB, the category is [ErrorUnknown].
+//
+// The handler is called in the background once the diagnosis finished,
+// after WinSparkle's error callback returned, while listeners added with
+// [AddListener] are called right away. [Cleanup] cancels pending diagnoses.
+func SetErrorHandler(fn func(err error)) error {
+	if fn == nil {
+		return SetErrorCallback(nil)
+	}
+	diag.setHandler(fn)
+	// The handler is called by dispatchEvent with the diagnosed error.
+	return setEventCallback(EventError, func() {})
 }
 
 // SetCanShutdownCallback sets callback for querying the application if it can
@@ -267,9 +391,8 @@ func SetErrorCallback(cb func()) {
 // before attempting to launch the installer. The callback returns `true` if
 // the host application can be safely shut down or `false` if not
 // (e.g. because the user has unsaved documents).
-func SetCanShutdownCallback(cb func() bool) {
-	fn := syscall.NewCallbackCDecl(func() uintptr { return boolean(cb()) })
-	winsparkle.NewProc("win_sparkle_set_can_shutdown_callback").Call(fn)
+func SetCanShutdownCallback(cb func() bool) error {
+	return canShutdownHook.set(cb, cb != nil)
 }
 
 // SetShutdownRequestCallback sets callback for shutting down the application.
@@ -277,9 +400,8 @@ func SetCanShutdownCallback(cb func() bool) {
 // This callback will be called to ask the host to shut down immediately after
 // launching the installer. Its implementation should gracefully terminate the
 // application.
-func SetShutdownRequestCallback(cb func()) {
-	fn := syscall.NewCallbackCDecl(func() uintptr { cb(); return 0 })
-	winsparkle.NewProc("win_sparkle_set_shutdown_request_callback").Call(fn)
+func SetShutdownRequestCallback(cb func()) error {
+	return setEventCallback(EventShutdownRequested, cb)
 }
 
 // SetDidFindUpdateCallback sets callback to be called when the updater did
@@ -287,9 +409,8 @@ func SetShutdownRequestCallback(cb func()) {
 //
 // This is useful in combination with [CheckUpdateWithUIAndInstall]
 // as it allows you to perform some action after WinSparkle checks for updates.
-func SetDidFindUpdateCallback(cb func()) {
-	fn := syscall.NewCallbackCDecl(func() uintptr { cb(); return 0 })
-	winsparkle.NewProc("win_sparkle_set_did_find_update_callback").Call(fn)
+func SetDidFindUpdateCallback(cb func()) error {
+	return setEventCallback(EventDidFindUpdate, cb)
 }
 
 // SetDidNotFindUpdateCallback sets callback to be called when the updater did
@@ -297,9 +418,8 @@ func SetDidFindUpdateCallback(cb func()) {
 //
 // This is useful in combination with [CheckUpdateWithUIAndInstall]
 // as it allows you to perform some action after WinSparkle checks for updates.
-func SetDidNotFindUpdateCallback(cb func()) {
-	fn := syscall.NewCallbackCDecl(func() uintptr { cb(); return 0 })
-	winsparkle.NewProc("win_sparkle_set_did_not_find_update_callback").Call(fn)
+func SetDidNotFindUpdateCallback(cb func()) error {
+	return setEventCallback(EventDidNotFindUpdate, cb)
 }
 
 // SetUpdateCancelledCallback sets callback to be called when the user cancels
@@ -308,9 +428,8 @@ func SetDidNotFindUpdateCallback(cb func()) {
 // This is useful in combination with [CheckUpdateWithUIAndInstall]
 // as it allows you to perform some action when the installation is
 // interrupted.
-func SetUpdateCancelledCallback(cb func()) {
-	fn := syscall.NewCallbackCDecl(func() uintptr { cb(); return 0 })
-	winsparkle.NewProc("win_sparkle_set_update_cancelled_callback").Call(fn)
+func SetUpdateCancelledCallback(cb func()) error {
+	return setEventCallback(EventUpdateCancelled, cb)
 }
 
 // SetUpdateSkippedCallback sets callback to be called when the user skips an
@@ -319,9 +438,8 @@ func SetUpdateCancelledCallback(cb func()) {
 // This is useful in combination with [CheckUpdateWithUIAndInstall]
 // or similar as it allows you to perform some action when the update is
 // skipped.
-func SetUpdateSkippedCallback(cb func()) {
-	fn := syscall.NewCallbackCDecl(func() uintptr { cb(); return 0 })
-	winsparkle.NewProc("win_sparkle_set_update_skipped_callback").Call(fn)
+func SetUpdateSkippedCallback(cb func()) error {
+	return setEventCallback(EventUpdateSkipped, cb)
 }
 
 // SetUpdatePostponedCallback sets callback to be called when the user
@@ -330,9 +448,8 @@ func SetUpdateSkippedCallback(cb func()) {
 // This is useful in combination with [CheckUpdateWithUI] or
 // similar as it allows you to perform some action when the download is
 // postponed.
-func SetUpdatePostponedCallback(cb func()) {
-	fn := syscall.NewCallbackCDecl(func() uintptr { cb(); return 0 })
-	winsparkle.NewProc("win_sparkle_set_update_postponed_callback").Call(fn)
+func SetUpdatePostponedCallback(cb func()) error {
+	return setEventCallback(EventUpdatePostponed, cb)
 }
 
 // SetUpdateDismissedCallback sets callback to be called when the user
@@ -340,9 +457,8 @@ func SetUpdatePostponedCallback(cb func()) {
 //
 // This is useful in combination with [CheckUpdateWithoutUI] or similar
 // as it allows you to perform some action when the update dialog is closed.
-func SetUpdateDismissedCallback(cb func()) {
-	fn := syscall.NewCallbackCDecl(func() uintptr { cb(); return 0 })
-	winsparkle.NewProc("win_sparkle_set_update_dismissed_callback").Call(fn)
+func SetUpdateDismissedCallback(cb func()) error {
+	return setEventCallback(EventUpdateDismissed, cb)
 }
 
 // SetUserRunInstallerCallback sets callback to be called when the update
@@ -352,15 +468,8 @@ func SetUpdateDismissedCallback(cb func()) {
 // The callback returns a boolean indicating whether the update was handled
 // and an error. If `handled` is `false` and there is no error WinSparkle's
 // default handling will take place.
-func SetUserRunInstallerCallback(cb func(file string) (handled bool, err error)) {
-	fn := syscall.NewCallbackCDecl(func(p *uint16) int {
-		ok, err := cb(utf16PtrToString(p))
-		if err != nil {
-			return -1
-		}
-		return int(boolean(ok))
-	})
-	winsparkle.NewProc("win_sparkle_set_user_run_installer_callback").Call(fn)
+func SetUserRunInstallerCallback(cb func(file string) (handled bool, err error)) error {
+	return userRunInstallerHook.set(cb, cb != nil)
 }
 
 // CheckUpdateWithUI checks if an update is available, showing progress UI to
@@ -380,8 +489,10 @@ func SetUserRunInstallerCallback(cb func(file string) (handled bool, err error))
 // Note: Because this function is intended for manual, user-initiated checks
 // for updates, it ignores "Skip this version" even if the user checked it
 // previously.
-func CheckUpdateWithUI() {
-	winsparkle.NewProc("win_sparkle_check_update_with_ui").Call()
+func CheckUpdateWithUI() error {
+	diag.start()
+	_, _, err := procCheckUpdateWithUI.call()
+	return err
 }
 
 // CheckUpdateWithUIAndInstall checks if an update is available, showing
@@ -396,8 +507,10 @@ func CheckUpdateWithUI() {
 // If your application expects to do something after checking for updates you
 // may wish to use [SetDidNotFindUpdateCallback] and
 // [SetUpdateCancelledCallback].
-func CheckUpdateWithUIAndInstall() {
-	winsparkle.NewProc("win_sparkle_check_update_with_ui_and_install").Call()
+func CheckUpdateWithUIAndInstall() error {
+	diag.start()
+	_, _, err := procCheckUpdateWithUIAndInstall.call()
+	return err
 }
 
 // CheckUpdateWithoutUI checks if an update is available.
@@ -412,6 +525,8 @@ func CheckUpdateWithUIAndInstall() {
 // This function returns immediately.
 //
 // Note: This function respects "Skip this version" choice by the user.
-func CheckUpdateWithoutUI() {
-	winsparkle.NewProc("win_sparkle_check_update_without_ui").Call()
+func CheckUpdateWithoutUI() error {
+	diag.start()
+	_, _, err := procCheckUpdateWithoutUI.call()
+	return err
 }
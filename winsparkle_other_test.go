@@ -0,0 +1,66 @@
+//go:build !windows
+
+package winsparkle_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/abemedia/go-winsparkle"
+	_ "github.com/abemedia/go-winsparkle/dll"
+)
+
+func TestUnsupported(t *testing.T) {
+	winsparkle.SetAppDetails("Test", "Test", "1.0")
+	winsparkle.SetAppcastURL("https://example.com/appcast.xml")
+	winsparkle.SetErrorCallback(func() { t.Error("should not call callback") })
+	winsparkle.Init()
+	defer winsparkle.Cleanup()
+
+	if err := winsparkle.CheckUpdateWithoutUI(); !errors.Is(err, winsparkle.ErrUnsupported) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := winsparkle.SetEdDSAPublicKey("pXAx0wfi8kGbeQln11+V4R3tCepSuLXeo7LkOeudc/U="); !errors.Is(err, winsparkle.ErrUnsupported) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if check := winsparkle.GetLastCheckTime(); !check.IsZero() {
+		t.Error("unexpected last check time:", check)
+	}
+	if winsparkle.GetAutomaticCheckForUpdates() {
+		t.Error("automatic check for updates should be disabled")
+	}
+}
+
+func TestCheckForUpdateUnsupported(t *testing.T) {
+	if _, err := winsparkle.CheckForUpdate(context.Background()); !errors.Is(err, winsparkle.ErrUnsupported) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoadUnsupported(t *testing.T) {
+	if err := winsparkle.Load(); !errors.Is(err, winsparkle.ErrUnsupported) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if winsparkle.Available() {
+		t.Error("should not be available")
+	}
+	if winsparkle.Supports(winsparkle.FeatureEdDSA) {
+		t.Error("should not support EdDSA")
+	}
+}
+
+func TestDefault(t *testing.T) {
+	var u winsparkle.Updater = winsparkle.Default
+
+	u.SetAppDetails("Test", "Test", "1.0")
+	u.Init()
+	defer u.Cleanup()
+
+	if err := u.SetEdDSAPublicKey("pXAx0wfi8kGbeQln11+V4R3tCepSuLXeo7LkOeudc/U="); !errors.Is(err, winsparkle.ErrUnsupported) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if i := u.GetUpdateCheckInterval(); i != 0 {
+		t.Error("unexpected update check interval:", i)
+	}
+}
@@ -3,6 +3,8 @@
 package winsparkle_test
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -22,8 +24,16 @@ func TestWinSparkle(t *testing.T) {
 	winsparkle.Init()
 	defer winsparkle.Cleanup()
 
-	winsparkle.CheckUpdateWithoutUI()
-	time.Sleep(time.Second) // Wait for update check.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := winsparkle.CheckForUpdate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != winsparkle.NoUpdateAvailable {
+		t.Error("unexpected result:", res)
+	}
 
 	check := winsparkle.GetLastCheckTime()
 	if !check.After(last) || !check.Before(time.Now()) {
@@ -31,6 +41,21 @@ func TestWinSparkle(t *testing.T) {
 	}
 }
 
+func TestLoad(t *testing.T) {
+	if err := winsparkle.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if !winsparkle.Available() {
+		t.Error("should be available")
+	}
+	if !winsparkle.Supports(winsparkle.FeatureEdDSA) {
+		t.Error("should support EdDSA")
+	}
+	if winsparkle.Supports(winsparkle.Feature("win_sparkle_nope")) {
+		t.Error("should not support missing function")
+	}
+}
+
 func TestSetErrorCallback(t *testing.T) {
 	winsparkle.SetAppDetails("Test", "Test", "1.0")
 	winsparkle.SetAppcastURL("nope")
@@ -41,8 +66,14 @@ func TestSetErrorCallback(t *testing.T) {
 	winsparkle.SetErrorCallback(func() {
 		ch <- struct{}{}
 	})
+	defer winsparkle.SetErrorCallback(nil)
 
-	winsparkle.CheckUpdateWithoutUI()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, err := winsparkle.CheckForUpdate(ctx); !errors.Is(err, winsparkle.ErrCheckFailed) {
+		t.Errorf("unexpected error: %v", err)
+	}
 
 	select {
 	case <-ch:
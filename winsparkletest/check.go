@@ -0,0 +1,294 @@
+package winsparkletest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/abemedia/go-winsparkle"
+	"github.com/abemedia/go-winsparkle/appcast"
+	"github.com/abemedia/go-winsparkle/internal/feed"
+	"github.com/abemedia/go-winsparkle/sign"
+)
+
+type checkMode int
+
+const (
+	checkWithUI checkMode = iota
+	checkWithUIAndInstall
+	checkWithoutUI
+)
+
+// LastError returns the [*winsparkle.UpdateError] which caused the last call
+// to the error callback, or nil if there was none.
+func (u *Updater) LastError() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.lastErr
+}
+
+// check starts an update check in the background.
+func (u *Updater) check(mode checkMode) {
+	u.wg.Add(1)
+	go func() {
+		defer u.wg.Done()
+		u.run(mode)
+	}()
+}
+
+// snapshot is a copy of the updater's settings taken when a check starts.
+type snapshot struct {
+	appcastURL     string
+	version        string
+	edDSAPublicKey string
+	dsaPublicKey   string
+	feed           *feed.Client
+	os             string
+	choice         Choice
+}
+
+func (u *Updater) snapshot() snapshot {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s := snapshot{
+		appcastURL:     u.appcastURL,
+		version:        u.version,
+		edDSAPublicKey: u.edDSAPublicKey,
+		dsaPublicKey:   u.dsaPublicKey,
+		feed: &feed.Client{
+			HTTP:      u.Client,
+			UserAgent: feed.UserAgent(u.app, u.version),
+			Headers:   u.headers,
+		},
+		os:     u.OS,
+		choice: u.Choice,
+	}
+	if u.build != "" {
+		s.version = u.build
+	}
+	return s
+}
+
+func (u *Updater) run(mode checkMode) {
+	s := u.snapshot()
+
+	a, uerr := s.fetchAppcast()
+	if uerr != nil {
+		u.fail(uerr)
+		return
+	}
+	u.settings().SetLastCheckTime(time.Now())
+
+	version, enc := feed.Latest(a, s.os)
+	if enc == nil || appcast.CompareVersions(version, s.version) <= 0 {
+		u.notify(winsparkle.EventDidNotFindUpdate, func() func() { return u.didNotFindCb })
+		return
+	}
+
+	if mode == checkWithoutUI {
+		if u.settings().SkippedVersion() == version {
+			u.notify(winsparkle.EventDidNotFindUpdate, func() func() { return u.didNotFindCb })
+			return
+		}
+	}
+
+	u.notify(winsparkle.EventDidFindUpdate, func() func() { return u.didFindCb })
+
+	choice := s.choice
+	if mode == checkWithUIAndInstall {
+		choice = Install
+	}
+
+	switch choice {
+	case Skip:
+		u.settings().SetSkippedVersion(version)
+		u.notify(winsparkle.EventUpdateSkipped, func() func() { return u.skippedCb })
+	case Postpone:
+		u.notify(winsparkle.EventUpdatePostponed, func() func() { return u.postponedCb })
+	case Dismiss:
+		u.notify(winsparkle.EventUpdateDismissed, func() func() { return u.dismissedCb })
+	case Cancel:
+		u.notify(winsparkle.EventUpdateCancelled, func() func() { return u.cancelledCb })
+	case Install:
+		u.install(&s, enc)
+	}
+}
+
+func (u *Updater) install(s *snapshot, enc *appcast.Enclosure) {
+	file, uerr := s.download(enc)
+	if uerr != nil {
+		u.fail(uerr)
+		return
+	}
+	defer os.RemoveAll(filepath.Dir(file))
+
+	u.mu.Lock()
+	runInstaller := u.userRunInstallerListeners.all()
+	if u.userRunInstaller != nil {
+		runInstaller = append([]func(string) (bool, error){u.userRunInstaller}, runInstaller...)
+	}
+	canShutdown := u.canShutdownListeners.all()
+	if u.canShutdownCb != nil {
+		canShutdown = append([]func() bool{u.canShutdownCb}, canShutdown...)
+	}
+	u.mu.Unlock()
+
+	for _, fn := range runInstaller {
+		handled, err := fn(file)
+		if err != nil {
+			u.fail(&winsparkle.UpdateError{
+				Category: winsparkle.ErrorInstaller,
+				Err:      fmt.Errorf("user run installer callback: %w", err),
+			})
+			return
+		}
+		if handled {
+			return
+		}
+	}
+
+	// WinSparkle asks the user to close the application if it can't be shut
+	// down and doesn't launch the installer.
+	for _, fn := range canShutdown {
+		if !fn() {
+			return
+		}
+	}
+
+	u.notify(winsparkle.EventShutdownRequested, func() func() { return u.shutdownCb })
+}
+
+// fail records the error and calls the error callback.
+func (u *Updater) fail(err *winsparkle.UpdateError) {
+	u.mu.Lock()
+	u.lastErr = err
+	u.mu.Unlock()
+	u.notify(winsparkle.EventError, func() func() { return u.errorCb })
+}
+
+// notify sends the event to the subscribers and calls the callback returned
+// by get, if it's set, followed by the listeners.
+func (u *Updater) notify(kind winsparkle.EventKind, get func() func()) {
+	e := winsparkle.Event{Kind: kind}
+	if kind == winsparkle.EventError {
+		e.Err = u.LastError()
+	}
+	u.events.Publish(e)
+
+	u.mu.Lock()
+	var fns []func()
+	if cb := get(); cb != nil {
+		fns = append(fns, cb)
+	}
+	if l, ok := u.eventListeners[kind]; ok {
+		fns = append(fns, l.all()...)
+	}
+	u.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+func (s *snapshot) fetchAppcast() (*appcast.Appcast, *winsparkle.UpdateError) {
+	u, err := url.Parse(s.appcastURL)
+	if err != nil {
+		return nil, &winsparkle.UpdateError{Err: err}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, &winsparkle.UpdateError{Err: fmt.Errorf("unsupported appcast URL %q", s.appcastURL)}
+	}
+
+	a, err := s.feed.Appcast(context.Background(), s.appcastURL)
+	if err != nil {
+		var (
+			statusErr *feed.StatusError
+			parseErr  *feed.ParseError
+		)
+		switch {
+		case errors.As(err, &statusErr):
+			return nil, &winsparkle.UpdateError{
+				Category:   winsparkle.ErrorHTTPStatus,
+				StatusCode: statusErr.StatusCode,
+				Err:        err,
+			}
+		case errors.As(err, &parseErr):
+			return nil, &winsparkle.UpdateError{Category: winsparkle.ErrorAppcast, Err: parseErr.Err}
+		default:
+			return nil, &winsparkle.UpdateError{Category: winsparkle.ErrorNetwork, Err: err}
+		}
+	}
+	return a, nil
+}
+
+// download downloads the update to a temporary directory and verifies its
+// signature.
+func (s *snapshot) download(enc *appcast.Enclosure) (string, *winsparkle.UpdateError) {
+	resp, err := s.feed.Get(context.Background(), enc.URL, false)
+	if err != nil {
+		uerr := &winsparkle.UpdateError{Category: winsparkle.ErrorDownload, Err: err}
+		var statusErr *feed.StatusError
+		if errors.As(err, &statusErr) {
+			uerr.StatusCode = statusErr.StatusCode
+		}
+		return "", uerr
+	}
+	defer resp.Body.Close()
+
+	dir, err := os.MkdirTemp("", "winsparkletest")
+	if err != nil {
+		return "", &winsparkle.UpdateError{Category: winsparkle.ErrorDownload, Err: err}
+	}
+	name := path.Base(resp.Request.URL.Path)
+	if name == "/" || name == "." {
+		name = "update"
+	}
+	file := filepath.Join(dir, name)
+
+	if uerr := s.save(file, resp.Body, enc); uerr != nil {
+		os.RemoveAll(dir)
+		return "", uerr
+	}
+	return file, nil
+}
+
+func (s *snapshot) save(file string, r io.Reader, enc *appcast.Enclosure) *winsparkle.UpdateError {
+	f, err := os.Create(file)
+	if err != nil {
+		return &winsparkle.UpdateError{Category: winsparkle.ErrorDownload, Err: err}
+	}
+	defer f.Close()
+
+	if _, err = io.Copy(f, r); err != nil {
+		return &winsparkle.UpdateError{Category: winsparkle.ErrorDownload, Err: err}
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return &winsparkle.UpdateError{Category: winsparkle.ErrorDownload, Err: err}
+	}
+
+	switch {
+	case s.edDSAPublicKey != "":
+		if enc.EdSignature == "" {
+			err = errors.New("update is not signed with EdDSA")
+		} else {
+			err = sign.VerifyEdDSA(s.edDSAPublicKey, f, enc.EdSignature)
+		}
+	case s.dsaPublicKey != "":
+		if enc.DSASignature == "" {
+			err = errors.New("update is not signed with DSA")
+		} else {
+			err = sign.VerifyDSA(s.dsaPublicKey, f, enc.DSASignature)
+		}
+	}
+	if err != nil {
+		return &winsparkle.UpdateError{Category: winsparkle.ErrorSignature, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package winsparkletest
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/abemedia/go-winsparkle"
+)
+
+// listeners holds functions in the order they were added.
+type listeners[F any] struct {
+	entries []listener[F]
+	nextID  int
+}
+
+type listener[F any] struct {
+	id int
+	fn F
+}
+
+// add adds fn while holding mu and returns a function removing it.
+func (l *listeners[F]) add(mu *sync.Mutex, fn F) func() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	id := l.nextID
+	l.nextID++
+	l.entries = append(l.entries, listener[F]{id: id, fn: fn})
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for i, e := range l.entries {
+			if e.id == id {
+				l.entries = append(l.entries[:i:i], l.entries[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// all returns the functions. The caller must hold the lock.
+func (l *listeners[F]) all() []F {
+	fns := make([]F, len(l.entries))
+	for i, e := range l.entries {
+		fns[i] = e.fn
+	}
+	return fns
+}
+
+// AddListener adds a function called when an event of the given kind occurs,
+// after the function passed to the Set*Callback method.
+func (u *Updater) AddListener(kind winsparkle.EventKind, fn func()) (remove func(), err error) {
+	if kind < winsparkle.EventDidFindUpdate || kind > winsparkle.EventShutdownRequested {
+		return nil, errors.New("winsparkletest: invalid event kind " + kind.String())
+	}
+	u.mu.Lock()
+	if u.eventListeners == nil {
+		u.eventListeners = map[winsparkle.EventKind]*listeners[func()]{}
+	}
+	l, ok := u.eventListeners[kind]
+	if !ok {
+		l = &listeners[func()]{}
+		u.eventListeners[kind] = l
+	}
+	u.mu.Unlock()
+	return l.add(&u.mu, fn), nil
+}
+
+// AddCanShutdownListener adds a function asking if the application can be
+// closed before running the installer. The installer only runs if all of them
+// return true.
+func (u *Updater) AddCanShutdownListener(fn func() bool) (remove func(), err error) {
+	return u.canShutdownListeners.add(&u.mu, fn), nil
+}
+
+// AddUserRunInstallerListener adds a function called with the downloaded
+// update. The functions are called in order until one of them handles the
+// update or returns an error.
+func (u *Updater) AddUserRunInstallerListener(fn func(file string) (handled bool, err error)) (remove func(), err error) {
+	return u.userRunInstallerListeners.add(&u.mu, fn), nil
+}
@@ -0,0 +1,400 @@
+// Package winsparkletest provides a fake [winsparkle.Updater] for testing.
+//
+// The fake doesn't need WinSparkle.dll and runs on all platforms. It fetches
+// and evaluates a real appcast, e.g. served by [net/http/httptest], and calls
+// the same callbacks WinSparkle would, answering WinSparkle's dialogs with a
+// scripted [Choice].
+package winsparkletest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abemedia/go-winsparkle"
+	"github.com/abemedia/go-winsparkle/config"
+	"github.com/abemedia/go-winsparkle/internal/broadcast"
+)
+
+// Choice is the simulated user's response to the update dialog.
+type Choice int
+
+// The choices available in WinSparkle's update dialog.
+const (
+	// Install downloads and installs the update.
+	Install Choice = iota
+
+	// Skip skips the update, which is remembered for future checks without UI.
+	Skip
+
+	// Postpone postpones the update, i.e. presses "remind me later".
+	Postpone
+
+	// Dismiss closes the update dialog.
+	Dismiss
+
+	// Cancel starts installing the update but cancels the download.
+	Cancel
+)
+
+// Updater is a fake [winsparkle.Updater] simulating WinSparkle's behaviour.
+//
+// The zero value is ready to use. Update checks run in the background like
+// they do in WinSparkle; use [Updater.Wait] to wait for them to complete.
+type Updater struct {
+	// Choice is the simulated user's response to the update dialog.
+	Choice Choice
+
+	// Client is used to download the appcast and updates. If nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+
+	// OS is the value of the appcast enclosures' sparkle:os attribute
+	// matching the simulated system, e.g. "windows-x64". Enclosures without
+	// the attribute or with "windows" always match. If empty, only those
+	// enclosures match.
+	OS string
+
+	mu sync.Mutex
+	wg sync.WaitGroup
+
+	appcastURL       string
+	app              string
+	version, build   string
+	edDSAPublicKey   string
+	dsaPublicKey     string
+	headers          [][2]string
+	config           winsparkle.ConfigStore
+	mem              config.MemoryStore
+	errorCb          func()
+	canShutdownCb    func() bool
+	shutdownCb       func()
+	didFindCb        func()
+	didNotFindCb     func()
+	cancelledCb      func()
+	skippedCb        func()
+	postponedCb      func()
+	dismissedCb      func()
+	userRunInstaller func(file string) (handled bool, err error)
+	lastErr          error
+	events           broadcast.Hub[winsparkle.Event]
+
+	eventListeners            map[winsparkle.EventKind]*listeners[func()]
+	canShutdownListeners      listeners[func() bool]
+	userRunInstallerListeners listeners[func(string) (bool, error)]
+}
+
+var _ winsparkle.Updater = (*Updater)(nil)
+
+// Init starts the updater and checks for updates if automatic checks are
+// enabled and the update check interval has elapsed.
+func (u *Updater) Init() error {
+	if !u.GetAutomaticCheckForUpdates() {
+		return nil
+	}
+	if last := u.GetLastCheckTime(); !last.IsZero() && time.Since(last) < u.GetUpdateCheckInterval() {
+		return nil
+	}
+	u.check(checkWithoutUI)
+	return nil
+}
+
+// Cleanup waits for running update checks to complete and stops the updater.
+func (u *Updater) Cleanup() error {
+	u.Wait()
+	return nil
+}
+
+// Wait waits for all running update checks to complete.
+func (u *Updater) Wait() {
+	u.wg.Wait()
+}
+
+// SetLang does nothing as the fake has no UI. Like WinSparkle, it returns an
+// error if lang contains a NUL character.
+func (u *Updater) SetLang(lang string) error { return checkString("lang", lang) }
+
+// SetLangID does nothing as the fake has no UI.
+func (u *Updater) SetLangID(uint16) error { return nil }
+
+// SetAppcastURL sets URL for the app's appcast.
+func (u *Updater) SetAppcastURL(url string) error {
+	if err := checkString("url", url); err != nil {
+		return err
+	}
+	u.mu.Lock()
+	u.appcastURL = url
+	u.mu.Unlock()
+	return nil
+}
+
+// SetDSAPubPEM sets the DSA public key used to verify updates.
+func (u *Updater) SetDSAPubPEM(pem string) error {
+	if err := checkString("pem", pem); err != nil {
+		return err
+	}
+	u.mu.Lock()
+	u.dsaPublicKey = pem
+	u.mu.Unlock()
+	return nil
+}
+
+// SetEdDSAPublicKey sets the EdDSA public key used to verify updates.
+func (u *Updater) SetEdDSAPublicKey(key string) error {
+	if err := checkString("key", key); err != nil {
+		return err
+	}
+	u.mu.Lock()
+	u.edDSAPublicKey = key
+	u.mu.Unlock()
+	return nil
+}
+
+// SetAppDetails sets application metadata.
+func (u *Updater) SetAppDetails(company, app, version string) error {
+	if err := checkString("company", company); err != nil {
+		return err
+	}
+	if err := checkString("app", app); err != nil {
+		return err
+	}
+	if err := checkString("version", version); err != nil {
+		return err
+	}
+	u.mu.Lock()
+	u.app, u.version = app, version
+	u.mu.Unlock()
+	return nil
+}
+
+// SetAppBuildVersion sets the application build version used to compare
+// versions.
+func (u *Updater) SetAppBuildVersion(build string) error {
+	if err := checkString("build", build); err != nil {
+		return err
+	}
+	u.mu.Lock()
+	u.build = build
+	u.mu.Unlock()
+	return nil
+}
+
+// SetHTTPHeader sets a custom HTTP header for appcast checks.
+func (u *Updater) SetHTTPHeader(name, value string) error {
+	if err := checkString("name", name); err != nil {
+		return err
+	}
+	if err := checkString("value", value); err != nil {
+		return err
+	}
+	u.mu.Lock()
+	u.headers = append(u.headers, [2]string{name, value})
+	u.mu.Unlock()
+	return nil
+}
+
+// ClearHTTPHeaders clears all custom HTTP headers.
+func (u *Updater) ClearHTTPHeaders() error {
+	u.mu.Lock()
+	u.headers = nil
+	u.mu.Unlock()
+	return nil
+}
+
+// SetRegistryPath does nothing as the fake doesn't use the registry. Like
+// WinSparkle, it returns an error if path contains a NUL character.
+func (u *Updater) SetRegistryPath(path string) error { return checkString("path", path) }
+
+// SetConfigMethods sets the store for configuration values. By default a
+// [config.MemoryStore] is used.
+func (u *Updater) SetConfigMethods(store winsparkle.ConfigStore) error {
+	u.mu.Lock()
+	u.config = store
+	u.mu.Unlock()
+	return nil
+}
+
+// SetAutomaticCheckForUpdates sets whether updates are checked automatically.
+func (u *Updater) SetAutomaticCheckForUpdates(check bool) error {
+	return u.settings().SetAutomaticCheckForUpdates(check)
+}
+
+// GetAutomaticCheckForUpdates gets the automatic update checking state.
+func (u *Updater) GetAutomaticCheckForUpdates() bool {
+	return u.settings().AutomaticCheckForUpdates()
+}
+
+// SetUpdateCheckInterval sets the interval between automatic update checks.
+// The minimum interval is one hour.
+func (u *Updater) SetUpdateCheckInterval(interval time.Duration) error {
+	return u.settings().SetUpdateCheckInterval(interval)
+}
+
+// GetUpdateCheckInterval gets the interval between automatic update checks.
+func (u *Updater) GetUpdateCheckInterval() time.Duration {
+	return u.settings().UpdateCheckInterval()
+}
+
+// GetLastCheckTime gets the time of the last update check.
+func (u *Updater) GetLastCheckTime() time.Time {
+	return u.settings().LastCheckTime()
+}
+
+// SetErrorCallback sets the callback called when the updater encounters an
+// error.
+func (u *Updater) SetErrorCallback(cb func()) error {
+	u.mu.Lock()
+	u.errorCb = cb
+	u.mu.Unlock()
+	return nil
+}
+
+// SetErrorHandler sets the callback called with an [*winsparkle.UpdateError]
+// when the updater encounters an error. It replaces the callback set by
+// [Updater.SetErrorCallback].
+func (u *Updater) SetErrorHandler(fn func(err error)) error {
+	var cb func()
+	if fn != nil {
+		cb = func() { fn(u.LastError()) }
+	}
+	return u.SetErrorCallback(cb)
+}
+
+// SetCanShutdownCallback sets the callback asking if the application can be
+// closed before running the installer.
+func (u *Updater) SetCanShutdownCallback(cb func() bool) error {
+	u.mu.Lock()
+	u.canShutdownCb = cb
+	u.mu.Unlock()
+	return nil
+}
+
+// SetShutdownRequestCallback sets the callback asking the application to shut
+// down after the installer was launched.
+func (u *Updater) SetShutdownRequestCallback(cb func()) error {
+	u.mu.Lock()
+	u.shutdownCb = cb
+	u.mu.Unlock()
+	return nil
+}
+
+// SetDidFindUpdateCallback sets the callback called when an update was found.
+func (u *Updater) SetDidFindUpdateCallback(cb func()) error {
+	u.mu.Lock()
+	u.didFindCb = cb
+	u.mu.Unlock()
+	return nil
+}
+
+// SetDidNotFindUpdateCallback sets the callback called when no update was
+// found.
+func (u *Updater) SetDidNotFindUpdateCallback(cb func()) error {
+	u.mu.Lock()
+	u.didNotFindCb = cb
+	u.mu.Unlock()
+	return nil
+}
+
+// SetUpdateCancelledCallback sets the callback called when the user cancels
+// the download.
+func (u *Updater) SetUpdateCancelledCallback(cb func()) error {
+	u.mu.Lock()
+	u.cancelledCb = cb
+	u.mu.Unlock()
+	return nil
+}
+
+// SetUpdateSkippedCallback sets the callback called when the user skips an
+// update.
+func (u *Updater) SetUpdateSkippedCallback(cb func()) error {
+	u.mu.Lock()
+	u.skippedCb = cb
+	u.mu.Unlock()
+	return nil
+}
+
+// SetUpdatePostponedCallback sets the callback called when the user postpones
+// an update.
+func (u *Updater) SetUpdatePostponedCallback(cb func()) error {
+	u.mu.Lock()
+	u.postponedCb = cb
+	u.mu.Unlock()
+	return nil
+}
+
+// SetUpdateDismissedCallback sets the callback called when the user dismisses
+// the update dialog.
+func (u *Updater) SetUpdateDismissedCallback(cb func()) error {
+	u.mu.Lock()
+	u.dismissedCb = cb
+	u.mu.Unlock()
+	return nil
+}
+
+// SetUserRunInstallerCallback sets the callback called with the downloaded
+// update.
+func (u *Updater) SetUserRunInstallerCallback(cb func(file string) (handled bool, err error)) error {
+	u.mu.Lock()
+	u.userRunInstaller = cb
+	u.mu.Unlock()
+	return nil
+}
+
+// Subscribe returns a channel receiving an event whenever a callback is
+// called. Events are dropped if the channel's buffer is full. A buffer
+// smaller than 1 is raised to 1.
+func (u *Updater) Subscribe(buffer int) (events <-chan winsparkle.Event, unsubscribe func()) {
+	return u.events.Subscribe(buffer)
+}
+
+// CheckUpdateWithUI checks for updates in the background, ignoring a skipped
+// version.
+func (u *Updater) CheckUpdateWithUI() error {
+	u.check(checkWithUI)
+	return nil
+}
+
+// CheckUpdateWithUIAndInstall checks for updates in the background and
+// installs an update without asking the user.
+func (u *Updater) CheckUpdateWithUIAndInstall() error {
+	u.check(checkWithUIAndInstall)
+	return nil
+}
+
+// CheckUpdateWithoutUI checks for updates in the background, respecting a
+// skipped version.
+func (u *Updater) CheckUpdateWithoutUI() error {
+	u.check(checkWithoutUI)
+	return nil
+}
+
+// checkString returns an error like WinSparkle if s can't be passed to it.
+func checkString(arg, s string) error {
+	if strings.IndexByte(s, 0) != -1 {
+		return &winsparkle.ArgError{Arg: arg}
+	}
+	return nil
+}
+
+// CheckForUpdate checks for updates like [Updater.CheckUpdateWithoutUI] and
+// waits for the result.
+func (u *Updater) CheckForUpdate(ctx context.Context) (winsparkle.Result, error) {
+	return winsparkle.WaitForResult(ctx, u, u.CheckUpdateWithoutUI)
+}
+
+// CheckForUpdateWithUI checks for updates like [Updater.CheckUpdateWithUI] and
+// waits for the result and the [Updater.Choice].
+func (u *Updater) CheckForUpdateWithUI(ctx context.Context) (winsparkle.Result, error) {
+	return winsparkle.WaitForResultWithUI(ctx, u, u.CheckUpdateWithUI)
+}
+
+func (u *Updater) settings() *config.Settings {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.config != nil {
+		return config.NewSettings(u.config)
+	}
+	return config.NewSettings(&u.mem)
+}
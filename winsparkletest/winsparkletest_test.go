@@ -0,0 +1,436 @@
+package winsparkletest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abemedia/go-winsparkle"
+	"github.com/abemedia/go-winsparkle/appcast"
+	"github.com/abemedia/go-winsparkle/sign"
+	"github.com/abemedia/go-winsparkle/winsparkletest"
+)
+
+const payload = "installer"
+
+func TestUpdater(t *testing.T) {
+	pub, priv, err := sign.GenerateEdDSAKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _, err := sign.SignEdDSA(priv, strings.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		version     string
+		choice      winsparkletest.Choice
+		signature   string
+		handled     bool
+		canShutdown bool
+		check       func(winsparkle.Updater) error
+		want        []string
+	}{
+		{
+			name:    "no update",
+			version: "2.0",
+			check:   winsparkle.Updater.CheckUpdateWithUI,
+			want:    []string{"did-not-find"},
+		},
+		{
+			name:        "install",
+			check:       winsparkle.Updater.CheckUpdateWithUI,
+			canShutdown: true,
+			want:        []string{"did-find", "user-run-installer", "can-shutdown", "shutdown"},
+		},
+		{
+			name:  "install without shutdown",
+			check: winsparkle.Updater.CheckUpdateWithUI,
+			want:  []string{"did-find", "user-run-installer", "can-shutdown"},
+		},
+		{
+			name:        "install and handled",
+			check:       winsparkle.Updater.CheckUpdateWithUI,
+			handled:     true,
+			canShutdown: true,
+			want:        []string{"did-find", "user-run-installer"},
+		},
+		{
+			name:        "install without asking",
+			choice:      winsparkletest.Dismiss,
+			check:       winsparkle.Updater.CheckUpdateWithUIAndInstall,
+			canShutdown: true,
+			want:        []string{"did-find", "user-run-installer", "can-shutdown", "shutdown"},
+		},
+		{
+			name:      "invalid signature",
+			signature: "aW52YWxpZA==",
+			check:     winsparkle.Updater.CheckUpdateWithUI,
+			want:      []string{"did-find", "error"},
+		},
+		{
+			name:   "skip",
+			choice: winsparkletest.Skip,
+			check: func(u winsparkle.Updater) error {
+				u.CheckUpdateWithUI()
+				u.(*winsparkletest.Updater).Wait()
+				u.CheckUpdateWithoutUI() // Respects skipped version.
+				u.(*winsparkletest.Updater).Wait()
+				return u.CheckUpdateWithUI() // Ignores skipped version.
+			},
+			want: []string{"did-find", "skipped", "did-not-find", "did-find", "skipped"},
+		},
+		{
+			name:   "postpone",
+			choice: winsparkletest.Postpone,
+			check:  winsparkle.Updater.CheckUpdateWithoutUI,
+			want:   []string{"did-find", "postponed"},
+		},
+		{
+			name:   "dismiss",
+			choice: winsparkletest.Dismiss,
+			check:  winsparkle.Updater.CheckUpdateWithoutUI,
+			want:   []string{"did-find", "dismissed"},
+		},
+		{
+			name:   "cancel",
+			choice: winsparkletest.Cancel,
+			check:  winsparkle.Updater.CheckUpdateWithUI,
+			want:   []string{"did-find", "cancelled"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signature := sig
+			if test.signature != "" {
+				signature = test.signature
+			}
+			version := "1.0"
+			if test.version != "" {
+				version = test.version
+			}
+
+			u := &winsparkletest.Updater{Choice: test.choice, OS: "windows-x64"}
+			u.SetAppDetails("Test", "Test", version)
+			u.SetAppcastURL(server(t, signature))
+			if err := u.SetEdDSAPublicKey(pub); err != nil {
+				t.Fatal(err)
+			}
+
+			var (
+				mu  sync.Mutex
+				got []string
+			)
+			event := func(name string) {
+				mu.Lock()
+				got = append(got, name)
+				mu.Unlock()
+			}
+			u.SetErrorCallback(func() { event("error") })
+			u.SetDidFindUpdateCallback(func() { event("did-find") })
+			u.SetDidNotFindUpdateCallback(func() { event("did-not-find") })
+			u.SetUpdateSkippedCallback(func() { event("skipped") })
+			u.SetUpdatePostponedCallback(func() { event("postponed") })
+			u.SetUpdateDismissedCallback(func() { event("dismissed") })
+			u.SetUpdateCancelledCallback(func() { event("cancelled") })
+			u.SetShutdownRequestCallback(func() { event("shutdown") })
+			u.SetCanShutdownCallback(func() bool {
+				event("can-shutdown")
+				return test.canShutdown
+			})
+			u.SetUserRunInstallerCallback(func(file string) (bool, error) {
+				event("user-run-installer")
+				b, err := os.ReadFile(file)
+				if err != nil || string(b) != payload {
+					t.Errorf("unexpected installer: %q, %v", b, err)
+				}
+				return test.handled, nil
+			})
+
+			u.Init()
+			if err := test.check(u); err != nil {
+				t.Fatal(err)
+			}
+			u.Cleanup()
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUpdaterInvalidString(t *testing.T) {
+	u := &winsparkletest.Updater{}
+	err := u.SetAppcastURL("https://example.com/\x00")
+	var argErr *winsparkle.ArgError
+	if !errors.As(err, &argErr) || argErr.Arg != "url" || !errors.Is(err, winsparkle.ErrInvalidValue) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUpdaterSubscribe(t *testing.T) {
+	u := &winsparkletest.Updater{Choice: winsparkletest.Skip, OS: "windows-x64"}
+	u.SetAppDetails("Test", "Test", "1.0")
+	u.SetAppcastURL(server(t, ""))
+
+	events, unsubscribe := u.Subscribe(10)
+	u.CheckUpdateWithUI()
+	u.Wait()
+	unsubscribe()
+
+	var got []winsparkle.EventKind
+	for e := range events {
+		got = append(got, e.Kind)
+	}
+	want := []winsparkle.EventKind{winsparkle.EventDidFindUpdate, winsparkle.EventUpdateSkipped}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestUpdaterListeners(t *testing.T) {
+	u := &winsparkletest.Updater{Choice: winsparkletest.Install, OS: "windows-x64"}
+	u.SetAppDetails("Test", "Test", "1.0")
+	u.SetAppcastURL(server(t, ""))
+
+	var got []string
+	u.SetDidFindUpdateCallback(func() { got = append(got, "callback") })
+	u.AddListener(winsparkle.EventDidFindUpdate, func() { got = append(got, "a") })
+	removeB, _ := u.AddListener(winsparkle.EventDidFindUpdate, func() { got = append(got, "b") })
+	u.AddCanShutdownListener(func() bool { got = append(got, "can-shutdown"); return false })
+	u.AddListener(winsparkle.EventShutdownRequested, func() { got = append(got, "shutdown") })
+
+	removeB()
+	u.CheckUpdateWithUI()
+	u.Wait()
+
+	if want := []string{"callback", "a", "can-shutdown"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q want %q", got, want)
+	}
+	if _, err := u.AddListener(0, func() {}); err == nil {
+		t.Error("should fail for invalid event kind")
+	}
+}
+
+func TestUpdaterCheckForUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		url     func() string
+		want    winsparkle.Result
+		err     error
+	}{
+		{name: "update", version: "1.0", want: winsparkle.UpdateAvailable},
+		{name: "no update", version: "2.0", want: winsparkle.NoUpdateAvailable},
+		{name: "error", version: "1.0", url: func() string { return "nope" }, err: winsparkle.ErrCheckFailed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := &winsparkletest.Updater{Choice: winsparkletest.Postpone, OS: "windows-x64"}
+			u.SetAppDetails("Test", "Test", test.version)
+			if test.url != nil {
+				u.SetAppcastURL(test.url())
+			} else {
+				u.SetAppcastURL(server(t, ""))
+			}
+			defer u.Cleanup()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			got, err := u.CheckForUpdate(ctx)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("got %v want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUpdaterCheckForUpdateWithUI(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		choice  winsparkletest.Choice
+		want    winsparkle.Result
+	}{
+		{name: "dismiss", version: "1.0", choice: winsparkletest.Dismiss, want: winsparkle.UpdateDismissed},
+		{name: "skip", version: "1.0", choice: winsparkletest.Skip, want: winsparkle.UpdateAvailable},
+		{name: "postpone", version: "1.0", choice: winsparkletest.Postpone, want: winsparkle.UpdateAvailable},
+		{name: "install", version: "1.0", choice: winsparkletest.Install, want: winsparkle.UpdateAvailable},
+		{name: "no update", version: "2.0", choice: winsparkletest.Dismiss, want: winsparkle.NoUpdateAvailable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := &winsparkletest.Updater{Choice: test.choice, OS: "windows-x64"}
+			u.SetAppDetails("Test", "Test", test.version)
+			u.SetAppcastURL(server(t, ""))
+			u.SetShutdownRequestCallback(func() {})
+			defer u.Cleanup()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			got, err := u.CheckForUpdateWithUI(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %v want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUpdaterError(t *testing.T) {
+	u := &winsparkletest.Updater{}
+	u.SetAppDetails("Test", "Test", "1.0")
+	u.SetAppcastURL("nope")
+
+	ch := make(chan struct{}, 1)
+	u.SetErrorCallback(func() { ch <- struct{}{} })
+	u.CheckUpdateWithoutUI()
+
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Error("should call callback")
+	}
+	if u.LastError() == nil {
+		t.Error("should record error")
+	}
+	if !u.GetLastCheckTime().IsZero() {
+		t.Error("should not update last check time")
+	}
+}
+
+func TestUpdaterErrorHandler(t *testing.T) {
+	pub, _, err := sign.GenerateEdDSAKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := httptest.NewServer(http.NotFoundHandler())
+	defer status.Close()
+	invalid := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<rss>"))
+	}))
+	defer invalid.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		key      string
+		handler  func(string) (bool, error)
+		category winsparkle.ErrorCategory
+		code     int
+	}{
+		{name: "http status", url: status.URL, category: winsparkle.ErrorHTTPStatus, code: http.StatusNotFound},
+		{name: "appcast", url: invalid.URL, category: winsparkle.ErrorAppcast},
+		{name: "signature", url: server(t, ""), key: pub, category: winsparkle.ErrorSignature},
+		{
+			name:     "installer",
+			url:      server(t, ""),
+			handler:  func(string) (bool, error) { return false, errors.New("failed") },
+			category: winsparkle.ErrorInstaller,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := &winsparkletest.Updater{OS: "windows-x64"}
+			u.SetAppDetails("Test", "Test", "1.0")
+			u.SetAppcastURL(test.url)
+			if test.key != "" {
+				u.SetEdDSAPublicKey(test.key)
+			}
+			u.SetUserRunInstallerCallback(test.handler)
+
+			ch := make(chan error, 1)
+			u.SetErrorHandler(func(err error) { ch <- err })
+			u.CheckUpdateWithUIAndInstall()
+			defer u.Cleanup()
+
+			var err error
+			select {
+			case err = <-ch:
+			case <-time.After(5 * time.Second):
+				t.Fatal("should call handler")
+			}
+			var updateErr *winsparkle.UpdateError
+			if !errors.As(err, &updateErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if updateErr.Category != test.category || updateErr.StatusCode != test.code {
+				t.Errorf("unexpected error: %v, status %d", updateErr.Category, updateErr.StatusCode)
+			}
+			if !errors.Is(err, winsparkle.ErrCheckFailed) {
+				t.Error("should match ErrCheckFailed")
+			}
+		})
+	}
+}
+
+func TestUpdaterAutomaticCheck(t *testing.T) {
+	u := &winsparkletest.Updater{}
+	u.SetAppDetails("Test", "Test", "2.0")
+	u.SetAppcastURL(server(t, ""))
+
+	u.SetUpdateCheckInterval(time.Minute)
+	if i := u.GetUpdateCheckInterval(); i != time.Hour {
+		t.Errorf("interval should be at least one hour: %s", i)
+	}
+
+	u.Init() // Automatic checks are disabled by default.
+	u.Wait()
+	if !u.GetLastCheckTime().IsZero() {
+		t.Error("should not check for updates")
+	}
+
+	u.SetAutomaticCheckForUpdates(true)
+	u.Init()
+	u.Cleanup()
+	if last := u.GetLastCheckTime(); time.Since(last) > time.Minute {
+		t.Error("unexpected last check time:", last)
+	}
+}
+
+func server(t *testing.T, signature string) string {
+	t.Helper()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/install.msi" {
+			w.Write([]byte(payload))
+			return
+		}
+
+		host := "http://" + r.Host
+		a := &appcast.Appcast{Channel: appcast.Channel{
+			Title: "WinSparkle Test Appcast",
+			Items: []appcast.Item{{
+				Title: "Version 2.0",
+				Enclosures: []appcast.Enclosure{
+					{URL: host + "/arm64.msi", Version: "2.0", OS: "windows-arm64"},
+					{URL: host + "/install.msi", Version: "2.0", OS: "windows-x64", EdSignature: signature},
+				},
+			}},
+		}}
+		w.Header().Set("Content-Type", "application/xml")
+		a.Encode(w)
+	}))
+	t.Cleanup(s.Close)
+	return s.URL
+}